@@ -8,6 +8,8 @@ package internal
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 
 	_ "embed"
@@ -34,6 +36,11 @@ const (
 	defaultPostgresDatabase      = "wisdom"
 	defaultOIDCAuthority         = "http://backend/api/auth/"
 	defaultAuthorizationRequired = true
+	defaultStationListInterval   = "24h"
+	defaultStationCatalogueTTL   = "48h"
+	defaultCatalogueInterval     = "24h"
+	defaultCacheMaxSize          = "2GB"
+	defaultCacheMaxAge           = "1h"
 )
 
 // Keys for common configuration entries.
@@ -49,6 +56,13 @@ const (
 	ConfigKey_Oidc_Authority        = "oidc.authority"
 	ConfigKey_Require_Authorization = "authorization.required"
 	ConfigKey_RedisURI              = "redis.uri"
+
+	ConfigKey_StationList_RefreshInterval      = "stationlist.refresh-interval"
+	ConfigKey_StationCatalogue_TTL             = "stationcatalogue.ttl"
+	ConfigKey_StationCatalogue_RefreshInterval = "stationcatalogue.refresh-interval"
+	ConfigKey_Cache_Directory                  = "cache.directory"
+	ConfigKey_Cache_MaxSize                    = "cache.max-size"
+	ConfigKey_Cache_MaxAge                     = "cache.max-age"
 )
 
 // envAliases contains all allowed environment variable names that are used to
@@ -64,6 +78,13 @@ var envAliases = map[string][]string{
 	ConfigKey_Oidc_Authority:        {"OIDC_AUTHORITY"},
 	ConfigKey_Require_Authorization: {"AUTH_REQUIRED"},
 	ConfigKey_RedisURI:              {"REDIS_URI", "REDIS_URL"},
+
+	ConfigKey_StationList_RefreshInterval:      {"STATION_LIST_REFRESH_INTERVAL"},
+	ConfigKey_StationCatalogue_TTL:             {"STATION_CATALOGUE_TTL"},
+	ConfigKey_StationCatalogue_RefreshInterval: {"STATION_CATALOGUE_REFRESH_INTERVAL"},
+	ConfigKey_Cache_Directory:                  {"CACHE_DIRECTORY", "CACHE_DIR"},
+	ConfigKey_Cache_MaxSize:                    {"CACHE_MAX_SIZE"},
+	ConfigKey_Cache_MaxAge:                     {"CACHE_MAX_AGE"},
 }
 
 // ParseConfiguration initializes the [Configuration] variable and reads the
@@ -122,6 +143,24 @@ func setDefaults(instance *viper.Viper) {
 	// routers this will work)
 	instance.SetDefault(ConfigKey_Require_Authorization, defaultAuthorizationRequired)
 
+	// setup the interval in which the station list for the v1 routes is
+	// crawled again
+	instance.SetDefault(ConfigKey_StationList_RefreshInterval, defaultStationListInterval)
+
+	// setup the lifetime of the v2 station catalogue entries and the interval
+	// in which they are refreshed in the background
+	instance.SetDefault(ConfigKey_StationCatalogue_TTL, defaultStationCatalogueTTL)
+	instance.SetDefault(ConfigKey_StationCatalogue_RefreshInterval, defaultCatalogueInterval)
+
+	// setup the directory in which downloaded archives are cached and the
+	// size the cached archives may occupy before old ones are removed
+	instance.SetDefault(ConfigKey_Cache_Directory, filepath.Join(os.TempDir(), ServiceName))
+	instance.SetDefault(ConfigKey_Cache_MaxSize, defaultCacheMaxSize)
+
+	// setup the time a cached archive without validators is used before it is
+	// downloaded again
+	instance.SetDefault(ConfigKey_Cache_MaxAge, defaultCacheMaxAge)
+
 }
 
 // bindEnvironmentVariables binds commonly used environment varialbes to
@@ -0,0 +1,230 @@
+package dwd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/redis"
+	v1 "microservice/types/v1"
+)
+
+// RedisKey_StationList is the key under which the brotli-compressed station
+// list used by the v1 routes is stored.
+const RedisKey_StationList = "dwd-station-list"
+
+// OpenDataClimateObservations is the base url of the climate observations
+// which are crawled to generate the station list.
+const OpenDataClimateObservations = "https://opendata.dwd.de/climate_environment/CDC/observations_germany/climate"
+
+// stationListSuffix is the suffix used by the DWD for the station description
+// files in the product folders.
+const stationListSuffix = "Beschreibung_Stationen.txt"
+
+// crawlerConcurrency limits the number of parallel requests sent to the
+// open data portal while crawling the station lists.
+const crawlerConcurrency = 8
+
+// RunStationListPrimer primes the station list once and afterward refreshes
+// it every interval until the context is canceled.
+// If the interval is not positive, the station list is only primed once.
+func RunStationListPrimer(ctx context.Context, interval time.Duration) {
+	prime := func() {
+		start := time.Now()
+		if err := PrimeStationList(ctx); err != nil {
+			slog.Error("unable to prime station list", "error", err)
+			return
+		}
+		slog.Info("primed station list", "duration", time.Since(start))
+	}
+
+	prime()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prime()
+		}
+	}
+}
+
+// PrimeStationList crawls the station lists of all climate observation
+// products, merges them into a single list of stations and stores it
+// brotli-compressed in redis.
+func PrimeStationList(ctx context.Context) error {
+	stationListUrls, err := discoverStationLists()
+	if err != nil {
+		return err
+	}
+
+	stations := make(map[string]*v1.Station)
+	var stationLock sync.Mutex
+
+	var group errgroup.Group
+	group.SetLimit(crawlerConcurrency)
+
+	for stationListUrl, capability := range stationListUrls {
+		group.Go(func() error {
+			res, err := http.Get(stationListUrl) //nolint:gosec
+			if err != nil {
+				return err
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != http.StatusOK {
+				return fmt.Errorf("%s: %w", stationListUrl, ErrResponseNotOK)
+			}
+
+			parsedStations, availability, err := ParseStationList(res.Body)
+			if err != nil {
+				return fmt.Errorf("%s: %w", stationListUrl, err)
+			}
+
+			stationLock.Lock()
+			defer stationLock.Unlock()
+			for idx, parsedStation := range parsedStations {
+				station, known := stations[parsedStation.ID]
+				if !known {
+					station = &parsedStation
+					stations[parsedStation.ID] = station
+				}
+
+				station.AddCapability(v1.Capability{
+					DataType:       capability.DataType,
+					Resolution:     capability.Resolution,
+					AvailableFrom:  availability[idx][0],
+					AvailableUntil: availability[idx][1],
+				})
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	stationList := make([]v1.Station, 0, len(stations))
+	for _, station := range stations {
+		if err := station.UpdateHistoricalState(); err != nil {
+			return err
+		}
+		stationList = append(stationList, *station)
+	}
+
+	slices.SortFunc(stationList, func(a, b v1.Station) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	var buf bytes.Buffer
+	writer := brotli.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(stationList); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return redis.Client().Set(ctx, RedisKey_StationList, buf.Bytes(), 0).Err()
+}
+
+// discoverStationLists walks the climate observation folders and returns the
+// urls of all station lists mapped to the capability they describe.
+func discoverStationLists() (map[string]v1.Capability, error) {
+	page, err := LoadIndexPage(OpenDataClimateObservations + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var productFolders []v1.Capability
+	var productFolderUrls []string
+	for _, resolutionUrl := range GetFolderURLs(page, OpenDataClimateObservations) {
+		resolution := v1.Resolution(0)
+		resolution.ParseString(path.Base(resolutionUrl))
+		// the multi-annual folder is organized by reference periods instead of
+		// products and therefore contains no station lists
+		if resolution == 0 || resolution == v1.MultiAnnually {
+			continue
+		}
+
+		page, err := LoadIndexPage(resolutionUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dataTypeUrl := range GetFolderURLs(page, strings.TrimSuffix(resolutionUrl, "/")) {
+			dataType := v1.DataType(0)
+			dataType.ParseString(path.Base(dataTypeUrl))
+			if dataType == 0 {
+				continue
+			}
+
+			productFolders = append(productFolders, v1.Capability{DataType: dataType, Resolution: resolution})
+			productFolderUrls = append(productFolderUrls, dataTypeUrl)
+		}
+	}
+
+	stationLists := make(map[string]v1.Capability)
+	var listLock sync.Mutex
+
+	var group errgroup.Group
+	group.SetLimit(crawlerConcurrency)
+
+	for idx, productFolderUrl := range productFolderUrls {
+		group.Go(func() error {
+			page, err := LoadIndexPage(productFolderUrl)
+			if err != nil {
+				return err
+			}
+
+			// station lists are either placed directly in the product folder
+			// or in the period folders (historical, recent, now)
+			folderUrls := []string{productFolderUrl}
+			folderUrls = append(folderUrls, GetFolderURLs(page, strings.TrimSuffix(productFolderUrl, "/"))...)
+
+			for folderIdx, folderUrl := range folderUrls {
+				if folderIdx > 0 {
+					page, err = LoadIndexPage(folderUrl)
+					if err != nil {
+						return err
+					}
+				}
+
+				for _, file := range FilterDocumentForFiles(page) {
+					if !strings.HasSuffix(file, stationListSuffix) {
+						continue
+					}
+
+					listLock.Lock()
+					stationLists[strings.TrimSuffix(folderUrl, "/")+"/"+file] = productFolders[idx]
+					listLock.Unlock()
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return stationLists, nil
+}
@@ -0,0 +1,102 @@
+package dwd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	geojson "github.com/paulmach/go.geojson"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+
+	v1 "microservice/types/v1"
+)
+
+// stationListHeaderLines is the number of lines at the start of a station list
+// which contain the column names and the separator line.
+const stationListHeaderLines = 2
+
+// stationListFixedColumns is the number of columns in front of the station
+// name which never contain whitespaces.
+const stationListFixedColumns = 6
+
+// stationListFeeColumn is the name of the column which is only present in
+// newer station lists and denotes if the data is available free of charge.
+const stationListFeeColumn = "Abgabe"
+
+var ErrMalformedStationList = errors.New("malformed station list")
+
+// ParseStationList reads a station description file
+// (`*_Beschreibung_Stationen.txt`) and returns the contained stations together
+// with the time range the station delivered data for in the file's product.
+func ParseStationList(r io.Reader) (stations []v1.Station, availability [][2]time.Time, err error) {
+	scanner := bufio.NewScanner(transform.NewReader(r, charmap.Windows1252.NewDecoder()))
+
+	var hasFeeColumn bool
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if lineNumber == 1 {
+			hasFeeColumn = len(fields) > 0 && fields[len(fields)-1] == stationListFeeColumn
+			continue
+		}
+		if lineNumber <= stationListHeaderLines || len(fields) == 0 {
+			continue
+		}
+
+		// the station name may contain whitespaces, therefore the columns
+		// after the name are read from the end of the line
+		trailingColumns := 1
+		if hasFeeColumn {
+			trailingColumns = 2
+		}
+		if len(fields) < stationListFixedColumns+trailingColumns+1 {
+			return nil, nil, ErrMalformedStationList
+		}
+
+		from, err := time.Parse(DateFormat_NoTime, fields[1])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		until, err := time.Parse(DateFormat_NoTime, fields[2])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		height, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		latitude, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		longitude, err := strconv.ParseFloat(fields[5], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nameEnd := len(fields) - trailingColumns
+		stations = append(stations, v1.Station{
+			ID:       fields[0],
+			Name:     strings.Join(fields[stationListFixedColumns:nameEnd], " "),
+			State:    fields[nameEnd],
+			Height:   height,
+			Location: geojson.NewPointGeometry([]float64{longitude, latitude}),
+		})
+		availability = append(availability, [2]time.Time{from, until})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return stations, availability, nil
+}
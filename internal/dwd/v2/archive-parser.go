@@ -1,10 +1,31 @@
 package v2
 
 import (
+	"iter"
+
 	"microservice/internal/dwd/v2/internal/parser"
 	v2 "microservice/types/v2"
 )
 
-func HandleArchive(filepath string) (datapoints []v2.Datapoint, metadata []v2.FieldMetadata, err error) {
-	return parser.ReadArchive(filepath)
+// Archive is an opened data archive. Its datapoints are read while iterating
+// over them.
+type Archive = parser.Archive
+
+// OpenArchive opens the downloaded archive and reads the metadata contained
+// in it.
+// The datapoints of the archive are tagged with the period of the data file.
+// If labels are supplied, only the parameters with these labels are read.
+func OpenArchive(file DataFile, labels []string) (*Archive, error) {
+	archive, err := parser.OpenArchive(file.Path, labels)
+	if err != nil {
+		return nil, err
+	}
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// MergeDatapoints merges the datapoints of multiple iterators, which are
+// ordered by their timestamps, into a single ordered iterator.
+func MergeDatapoints(sequences ...iter.Seq2[v2.Datapoint, error]) iter.Seq2[v2.Datapoint, error] {
+	return parser.MergeDatapoints(sequences...)
 }
@@ -0,0 +1,336 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	ClimatUrlKey  = "climat"
+	ClimatBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/observations_global/CLIMAT/"
+)
+
+// climatQualityControlledFolder contains the quality controlled CLIMAT
+// observations in the granularity folders.
+const climatQualityControlledFolder = "qc"
+
+var errClimatStationListMissing = errors.New("no CLIMAT station list found")
+
+// Climat contains the monthly CLIMAT observations of the stations worldwide,
+// which are identified by their WMO ids.
+// The observations are published as a file per station and parameter in the
+// period folders of the parameters, while the coordinates of the stations are
+// published in a separate station list.
+var Climat Database = climat{}
+
+// AvailableClimatProducts contains a mapping of the CLIMAT products to the
+// available granularities.
+var AvailableClimatProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Monthly: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.ClimateObservation_Pressure,
+		dwdTypes.ClimateObservation_Sun,
+	},
+}
+
+// climatParameter describes the files of a CLIMAT product.
+type climatParameter struct {
+	// Folder is the name of the parameter folder containing the files.
+	Folder string
+
+	// Metadata describes the values, since the files contain no metadata.
+	Metadata v2.FieldMetadata
+}
+
+// climatParameters maps the CLIMAT products to their parameter folders.
+var climatParameters = map[Product]climatParameter{
+	dwdTypes.AirTemperatureMean: {
+		Folder: "air_temperature_mean",
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.AirTemperatureMean.String(),
+			Description: "Monatsmittel der Lufttemperatur",
+			Unit:        "°C",
+		},
+	},
+	dwdTypes.ClimateObservation_Precipitation: {
+		Folder: "precipitation_total",
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.ClimateObservation_Precipitation.String(),
+			Description: "Monatssumme der Niederschlagshöhe",
+			Unit:        "mm",
+		},
+	},
+	dwdTypes.ClimateObservation_Pressure: {
+		Folder: "mean_sea_level_pressure",
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.ClimateObservation_Pressure.String(),
+			Description: "Monatsmittel des Luftdrucks auf Meereshöhe",
+			Unit:        "hPa",
+		},
+	},
+	dwdTypes.ClimateObservation_Sun: {
+		Folder: "sunshine_duration",
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.ClimateObservation_Sun.String(),
+			Description: "Monatssumme der Sonnenscheindauer",
+			Unit:        "h",
+		},
+	},
+}
+
+// climatFile is a datafile listed in a period folder of a parameter.
+type climatFile struct {
+	periodFile
+	stationID string
+	covered   v2.DateTimeRange
+}
+
+type climat struct{}
+
+func (climat) Name() string {
+	return ClimatUrlKey
+}
+
+func (climat) BaseUrl() string {
+	return ClimatBaseUrl
+}
+
+func (climat) Products() map[Granularity][]Product {
+	return AvailableClimatProducts
+}
+
+// DiscoverStations discovers the stations with a datafile of the product and
+// locates them using the CLIMAT station list.
+// The recent files carry no range in their names, so they are assumed to
+// cover the range following the historical files instead of reading every
+// recent file.
+func (db climat) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	if !SupportsProduct(db, granularity, product) {
+		return nil, errUnsupportedProduct
+	}
+
+	files, descriptionFiles, stationList, err := db.listFiles(granularity, product, nil)
+	if err != nil {
+		return nil, err
+	}
+	ReleaseFiles(nil, descriptionFiles)
+	if stationList == "" {
+		return nil, errClimatStationListMissing
+	}
+
+	recent := climatRecentRange(files, time.Now())
+	availability := make(map[string]v2.DateTimeRange)
+	for _, file := range files {
+		covered := file.covered
+		if covered.Start.IsZero() {
+			covered = recent
+		}
+
+		available, known := availability[file.stationID]
+		if !known || covered.Start.Before(available.Start) {
+			available.Start = covered.Start
+		}
+		if !known || covered.End.After(available.End) {
+			available.End = covered.End
+		}
+		availability[file.stationID] = available
+	}
+
+	filepath, err := dwd.Download(stationList)
+	if err != nil {
+		return nil, err
+	}
+	defer dwd.Release(filepath)
+	parsedStations, err := parser.ReadClimatStations(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", stationList, err)
+	}
+
+	var stations []v2.Station
+	for _, station := range parsedStations {
+		available, found := availability[station.ID]
+		if !found {
+			continue
+		}
+		station.SupportedProducts = map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+			product: {
+				granularity: available,
+			},
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, nil
+}
+
+// DownloadFiles downloads the datafiles of the station in the period folders
+// selected by the filter.
+// The historical files are limited to the ones covering the range of the
+// filter.
+func (db climat) DownloadFiles(stationID string, product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	files, descriptionFiles, _, err := db.listFiles(granularity, product, filter.Periods)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	var l sync.Mutex
+	var dataFiles []DataFile
+
+	for _, file := range files {
+		if file.stationID != stationID {
+			continue
+		}
+		if !file.covered.Start.IsZero() && !fileOverlaps(file.covered.Start, file.covered.End, filter) {
+			continue
+		}
+
+		group.Go(func() error {
+			filepath, err := dwd.Download(file.uri)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: file.name, Period: file.period})
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, descriptionFiles)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+func (climat) OpenDataFile(file DataFile, stationID string, product Product, _ Granularity, labels []string) (*Archive, error) { //nolint:lll
+	parameter, supported := climatParameters[product]
+	if !supported {
+		return nil, errUnsupportedProduct
+	}
+
+	archive, err := parser.OpenClimatObservations(file.Path, stationID, parameter.Metadata, labels)
+	if err != nil {
+		return nil, err
+	}
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// listFiles lists the datafiles of the product in the period folders and
+// downloads the description files placed in the parameter folder.
+// The station list is searched in the parameter folder and the folder of the
+// granularity, and its url is returned if it has been found.
+// If no period is supplied, the datafiles of all periods are listed.
+func (db climat) listFiles(granularity Granularity, product Product, periods []Period) (files []climatFile, descriptions [][2]string, stationList string, err error) { //nolint:lll
+	parameter, supported := climatParameters[product]
+	if !supported {
+		return nil, nil, "", errUnsupportedProduct
+	}
+
+	granularityUri, err := url.JoinPath(db.BaseUrl(), granularity.UrlPart(), climatQualityControlledFolder)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	parameterUri, err := url.JoinPath(granularityUri, parameter.Folder)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	parameterFiles, folders, err := readFolder(parameterUri)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	granularityFiles, _, err := readFolder(granularityUri)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var descriptionFiles []string
+	for _, file := range parameterFiles {
+		if isClimatStationList(file) && stationList == "" {
+			stationList, err = url.JoinPath(parameterUri, file)
+			if err != nil {
+				return nil, nil, "", err
+			}
+			continue
+		}
+		descriptionFiles = append(descriptionFiles, file)
+	}
+	for _, file := range granularityFiles {
+		if isClimatStationList(file) && stationList == "" {
+			stationList, err = url.JoinPath(granularityUri, file)
+			if err != nil {
+				return nil, nil, "", err
+			}
+		}
+	}
+
+	periodFiles, err := listPeriodFiles(parameterUri, folders, periods)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	for _, file := range periodFiles {
+		stationID, covered, ok := parser.ParseClimatFileName(file.name)
+		if !ok {
+			continue
+		}
+		files = append(files, climatFile{periodFile: file, stationID: stationID, covered: covered})
+	}
+
+	descriptions, err = downloadDescriptionFiles(parameterUri, descriptionFiles)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return files, descriptions, stationList, nil
+}
+
+// climatRecentRange returns the range assumed to be covered by the recent
+// files, which starts after the last month covered by the historical files
+// and ends with the last month before now.
+// If no historical files are listed, the recent files are assumed to start
+// with the previous year.
+func climatRecentRange(files []climatFile, now time.Time) v2.DateTimeRange {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+
+	var start time.Time
+	for _, file := range files {
+		if next := file.covered.End.AddDate(0, 0, 1); !file.covered.End.IsZero() && next.After(start) {
+			start = next
+		}
+	}
+	if start.IsZero() {
+		start = time.Date(now.Year()-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	// the recent files cover at least the last month
+	if start.After(end) {
+		start = time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return v2.DateTimeRange{Start: start, End: end}
+}
+
+// isClimatStationList checks if the file is a CLIMAT station list.
+func isClimatStationList(file string) bool {
+	file = strings.ToLower(file)
+	return strings.Contains(file, "station") && strings.HasSuffix(file, ".txt")
+}
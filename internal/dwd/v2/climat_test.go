@@ -0,0 +1,52 @@
+package v2
+
+import (
+	"testing"
+	"time"
+
+	v2 "microservice/types/v2"
+)
+
+func TestClimatRecentRange(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	now := date(2024, time.October, 18)
+
+	tests := []struct {
+		name     string
+		files    []climatFile
+		expected v2.DateTimeRange
+	}{
+		{
+			name: "after the historical files",
+			files: []climatFile{
+				{stationID: "10488", covered: v2.DateTimeRange{Start: date(1951, 1, 1), End: date(2022, 12, 31)}},
+				{stationID: "10488"},
+				{stationID: "01001", covered: v2.DateTimeRange{Start: date(1990, 1, 1), End: date(2023, 12, 31)}},
+			},
+			expected: v2.DateTimeRange{Start: date(2024, 1, 1), End: date(2024, 9, 30)},
+		},
+		{
+			name:     "without historical files",
+			files:    []climatFile{{stationID: "10488"}},
+			expected: v2.DateTimeRange{Start: date(2023, 1, 1), End: date(2024, 9, 30)},
+		},
+		{
+			name: "historical files up to the last month",
+			files: []climatFile{
+				{stationID: "10488", covered: v2.DateTimeRange{Start: date(1951, 1, 1), End: date(2024, 9, 30)}},
+			},
+			expected: v2.DateTimeRange{Start: date(2024, 9, 1), End: date(2024, 9, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recent := climatRecentRange(tt.files, now)
+			if !recent.Start.Equal(tt.expected.Start) || !recent.End.Equal(tt.expected.End) {
+				t.Errorf("expected %+v, got %+v", tt.expected, recent)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+const (
+	ClimateObservationsUrlKey  = "climateObservations"
+	ClimateObservationsBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/observations_germany/climate/"
+)
+
+// ClimateObservations contains the observations of the German climate
+// stations.
+// The products are organized in folders per granularity and product which
+// are split into the periods of the observations, except for the multi-annual
+// means which are organized by their reference periods.
+var ClimateObservations Database = climateObservations{}
+
+type climateObservations struct{}
+
+func (climateObservations) Name() string {
+	return ClimateObservationsUrlKey
+}
+
+func (climateObservations) BaseUrl() string {
+	return ClimateObservationsBaseUrl
+}
+
+func (climateObservations) Products() map[Granularity][]Product {
+	return AvailableClimateObservationProducts
+}
+
+func (db climateObservations) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	if !SupportsProduct(db, granularity, product) {
+		return nil, errUnsupportedProduct
+	}
+
+	if granularity == dwdTypes.Granularity_MultiAnnual {
+		return discoverMultiAnnualStations(db.BaseUrl(), product)
+	}
+	return discoverStations(db.BaseUrl(), granularity, product)
+}
+
+func (db climateObservations) DownloadFiles(stationID string, product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	if granularity == dwdTypes.Granularity_MultiAnnual {
+		return downloadMultiAnnualFiles(db.BaseUrl(), product)
+	}
+	return downloadFiles(db.BaseUrl(), stationID, product, granularity, filter)
+}
+
+func (climateObservations) OpenDataFile(file DataFile, stationID string, product Product, granularity Granularity, labels []string) (*Archive, error) { //nolint:lll
+	if granularity == dwdTypes.Granularity_MultiAnnual {
+		return OpenMultiAnnualMeans(file, stationID, product, labels)
+	}
+	return OpenArchive(file, labels)
+}
@@ -1,14 +1,92 @@
 package v2
 
-const (
-	ClimateObservationsUrlKey  = "climateObservations"
-	ClimateObservationsBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/observations_germany/climate/"
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	v2 "microservice/types/v2"
 )
 
-var Databases = map[string]string{
-	ClimateObservationsUrlKey: ClimateObservationsBaseUrl,
+var errUnknownDatabase = errors.New("unknown database")
+
+// Database is a dataset offered on the OpenData Portal.
+// Each database describes the products it offers and knows how to discover
+// its stations as well as how to download and read its datafiles, which
+// allows the routes to handle all databases the same way.
+type Database interface {
+	// Name returns the key used to select the database.
+	Name() string
+
+	// BaseUrl returns the url of the database on the OpenData Portal.
+	// It is also used to check if the database is reachable.
+	BaseUrl() string
+
+	// Products returns the products available in each of the granularities.
+	Products() map[Granularity][]Product
+
+	// DiscoverStations discovers the stations offering the product in the
+	// granularity.
+	DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error)
+
+	// DownloadFiles downloads the datafiles of the station for the product in
+	// the granularity and (if available) the description files of the
+	// product.
+	DownloadFiles(stationID string, product Product, granularity Granularity, filter DownloadFilter) (datafiles []DataFile, descriptions [][2]string, err error) //nolint:lll
+
+	// OpenDataFile opens a datafile downloaded by DownloadFiles.
+	// If labels are supplied, only the parameters with these labels are read.
+	OpenDataFile(file DataFile, stationID string, product Product, granularity Granularity, labels []string) (*Archive, error) //nolint:lll
+}
+
+// ForecastDatabase is a database offering forecasts instead of observations.
+// The availability stored for its stations only covers the forecast run which
+// was current while discovering the stations, so it does not limit the ranges
+// which may be requested.
+type ForecastDatabase interface {
+	Database
+
+	// Forecasts marks the database as offering forecasts.
+	Forecasts()
+}
+
+// IsForecast checks if the database offers forecasts.
+func IsForecast(database Database) bool {
+	_, isForecast := database.(ForecastDatabase)
+	return isForecast
+}
+
+// databases contains the databases offered by the service mapped to their
+// names.
+var databases = map[string]Database{
+	ClimatUrlKey:              Climat,
+	ClimateObservationsUrlKey: ClimateObservations,
+	DerivedSoilUrlKey:         DerivedSoil,
+	MosmixUrlKey:              Mosmix,
+	PhenologyUrlKey:           Phenology,
+	RegionalAveragesUrlKey:    RegionalAverages,
+}
+
+// LookupDatabase returns the database with the name.
+func LookupDatabase(name string) (Database, bool) {
+	database, found := databases[name]
+	return database, found
+}
+
+// Databases returns all databases offered by the service ordered by their
+// names.
+func Databases() []Database {
+	names := slices.Sorted(maps.Keys(databases))
+
+	result := make([]Database, len(names))
+	for idx, name := range names {
+		result[idx] = databases[name]
+	}
+	return result
 }
 
-var Products = map[string]map[Granularity][]Product{
-	ClimateObservationsUrlKey: AvailableClimateObservationProducts,
+// SupportsProduct checks if the database offers the product in the
+// granularity.
+func SupportsProduct(database Database, granularity Granularity, product Product) bool {
+	return slices.Contains(database.Products()[granularity], product)
 }
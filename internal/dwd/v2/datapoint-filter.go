@@ -0,0 +1,111 @@
+package v2
+
+import (
+	"iter"
+	"time"
+
+	v2 "microservice/types/v2"
+)
+
+// FilterTimeRange returns an iterator which only yields the datapoints with a
+// timestamp between start and end (both inclusive).
+// A zero start or end leaves the range open on that side.
+// Since the datapoints are expected to be ordered by their timestamps, the
+// iteration is stopped after the first datapoint after the end.
+func FilterTimeRange(datapoints iter.Seq2[v2.Datapoint, error], start, end time.Time) iter.Seq2[v2.Datapoint, error] { //nolint:lll
+	return func(yield func(v2.Datapoint, error) bool) {
+		for dp, err := range datapoints {
+			if err != nil {
+				yield(dp, err)
+				return
+			}
+
+			if !start.IsZero() && dp.Timestamp.Before(start) {
+				continue
+			}
+
+			if !end.IsZero() && dp.Timestamp.After(end) {
+				return
+			}
+
+			if !yield(dp, nil) {
+				return
+			}
+		}
+	}
+}
+
+// DeduplicateDatapoints returns an iterator which only yields a single
+// datapoint for each timestamp and label.
+// The periods of the observations overlap, so the same value may be contained
+// in the historical, recent and current data files.
+// Datapoints containing a value are preferred over missing values, and
+// otherwise the datapoint of the preferred period (historical before recent
+// before now) is kept.
+// If neither rule decides, the first datapoint is kept, which makes the result
+// deterministic as long as the input is.
+// The datapoints need to be ordered by their timestamps.
+func DeduplicateDatapoints(datapoints iter.Seq2[v2.Datapoint, error]) iter.Seq2[v2.Datapoint, error] {
+	return func(yield func(v2.Datapoint, error) bool) {
+		var timestamp time.Time
+		var labels []string
+		selected := make(map[string]v2.Datapoint)
+
+		emit := func() bool {
+			for _, label := range labels {
+				if !yield(selected[label], nil) {
+					return false
+				}
+			}
+			labels = labels[:0]
+			clear(selected)
+			return true
+		}
+
+		for dp, err := range datapoints {
+			if err != nil {
+				yield(dp, err)
+				return
+			}
+
+			if !dp.Timestamp.Equal(timestamp) {
+				if !emit() {
+					return
+				}
+				timestamp = dp.Timestamp
+			}
+
+			current, found := selected[dp.Label]
+			if !found {
+				labels = append(labels, dp.Label)
+				selected[dp.Label] = dp
+				continue
+			}
+
+			if preferDatapoint(dp, current) {
+				selected[dp.Label] = dp
+			}
+		}
+
+		emit()
+	}
+}
+
+// preferDatapoint reports if the candidate replaces the currently selected
+// datapoint with the same timestamp and label.
+func preferDatapoint(candidate, current v2.Datapoint) bool {
+	candidateMissing, currentMissing := isMissing(candidate), isMissing(current)
+	if candidateMissing != currentMissing {
+		return currentMissing
+	}
+	return candidate.Period.PreferredOver(current.Period)
+}
+
+// isMissing checks if the datapoint contains no value.
+func isMissing(dp v2.Datapoint) bool {
+	if dp.Value == nil {
+		return true
+	}
+	value, isFloat := dp.Value.(float64)
+	return isFloat && value == missingValue
+}
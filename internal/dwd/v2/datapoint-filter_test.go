@@ -0,0 +1,124 @@
+package v2
+
+import (
+	"testing"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+func TestDeduplicateDatapoints(t *testing.T) {
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	datapoint := func(value any, period dwdTypes.Period) v2.Datapoint {
+		return v2.Datapoint{Label: "TMK", Timestamp: timestamp, Value: value, Period: period}
+	}
+
+	tests := []struct {
+		name       string
+		datapoints []v2.Datapoint
+		value      any
+		period     dwdTypes.Period
+	}{
+		{
+			name:       "historical before recent",
+			datapoints: []v2.Datapoint{datapoint(2.0, dwdTypes.Period_Recent), datapoint(1.0, dwdTypes.Period_Historical)},
+			value:      1.0,
+			period:     dwdTypes.Period_Historical,
+		},
+		{
+			name:       "recent before now",
+			datapoints: []v2.Datapoint{datapoint(3.0, dwdTypes.Period_Now), datapoint(2.0, dwdTypes.Period_Recent)},
+			value:      2.0,
+			period:     dwdTypes.Period_Recent,
+		},
+		{
+			name: "historical before recent and now",
+			datapoints: []v2.Datapoint{
+				datapoint(3.0, dwdTypes.Period_Now),
+				datapoint(1.0, dwdTypes.Period_Historical),
+				datapoint(2.0, dwdTypes.Period_Recent),
+			},
+			value:  1.0,
+			period: dwdTypes.Period_Historical,
+		},
+		{
+			name:       "value before missing historical value",
+			datapoints: []v2.Datapoint{datapoint(nil, dwdTypes.Period_Historical), datapoint(2.0, dwdTypes.Period_Recent)},
+			value:      2.0,
+			period:     dwdTypes.Period_Recent,
+		},
+		{
+			name:       "value before missing value marker",
+			datapoints: []v2.Datapoint{datapoint(-999.0, dwdTypes.Period_Historical), datapoint(3.0, dwdTypes.Period_Now)},
+			value:      3.0,
+			period:     dwdTypes.Period_Now,
+		},
+		{
+			name:       "missing recent value after historical value",
+			datapoints: []v2.Datapoint{datapoint(1.0, dwdTypes.Period_Historical), datapoint(nil, dwdTypes.Period_Recent)},
+			value:      1.0,
+			period:     dwdTypes.Period_Historical,
+		},
+		{
+			name:       "only missing values prefer the period",
+			datapoints: []v2.Datapoint{datapoint(nil, dwdTypes.Period_Now), datapoint(-999.0, dwdTypes.Period_Recent)},
+			value:      -999.0,
+			period:     dwdTypes.Period_Recent,
+		},
+		{
+			name:       "first datapoint without a period",
+			datapoints: []v2.Datapoint{datapoint(1.0, dwdTypes.Period_None), datapoint(2.0, dwdTypes.Period_None)},
+			value:      1.0,
+			period:     dwdTypes.Period_None,
+		},
+		{
+			name:       "period before no period",
+			datapoints: []v2.Datapoint{datapoint(1.0, dwdTypes.Period_None), datapoint(2.0, dwdTypes.Period_Now)},
+			value:      2.0,
+			period:     dwdTypes.Period_Now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deduplicated := collect(t, DeduplicateDatapoints(sequence(tt.datapoints...)))
+			if len(deduplicated) != 1 {
+				t.Fatalf("expected a single datapoint, got %v", deduplicated)
+			}
+			if deduplicated[0].Value != tt.value || deduplicated[0].Period != tt.period {
+				t.Errorf("expected %v from %s, got %v from %s", tt.value, tt.period,
+					deduplicated[0].Value, deduplicated[0].Period)
+			}
+		})
+	}
+}
+
+func TestDeduplicateDatapointsKeepsOrder(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	deduplicated := collect(t, DeduplicateDatapoints(sequence(
+		v2.Datapoint{Label: "TT_TU", Timestamp: first, Value: 1.0, Period: dwdTypes.Period_Historical},
+		v2.Datapoint{Label: "RF_TU", Timestamp: first, Value: 80.0, Period: dwdTypes.Period_Historical},
+		v2.Datapoint{Label: "RF_TU", Timestamp: first, Value: 81.0, Period: dwdTypes.Period_Recent},
+		v2.Datapoint{Label: "TT_TU", Timestamp: first, Value: 1.5, Period: dwdTypes.Period_Recent},
+		v2.Datapoint{Label: "TT_TU", Timestamp: second, Value: 2.0, Period: dwdTypes.Period_Recent},
+		v2.Datapoint{Label: "TT_TU", Timestamp: second, Value: 2.5, Period: dwdTypes.Period_Now},
+	)))
+
+	expected := []v2.Datapoint{
+		{Label: "TT_TU", Timestamp: first, Value: 1.0, Period: dwdTypes.Period_Historical},
+		{Label: "RF_TU", Timestamp: first, Value: 80.0, Period: dwdTypes.Period_Historical},
+		{Label: "TT_TU", Timestamp: second, Value: 2.0, Period: dwdTypes.Period_Recent},
+	}
+	if len(deduplicated) != len(expected) {
+		t.Fatalf("expected %d datapoints, got %v", len(expected), deduplicated)
+	}
+	for idx, dp := range expected {
+		got := deduplicated[idx]
+		if got.Label != dp.Label || !got.Timestamp.Equal(dp.Timestamp) || got.Value != dp.Value || got.Period != dp.Period {
+			t.Errorf("datapoint %d: expected %+v, got %+v", idx, dp, got)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package v2
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	DerivedSoilUrlKey  = "derivedSoil"
+	DerivedSoilBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/derived_germany/soil/"
+)
+
+// derivedSoilStart is the first day the derived soil products are calculated
+// for.
+var derivedSoilStart = time.Date(1991, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DerivedSoil contains the soil moisture, soil temperature and
+// evapotranspiration calculated by the agrometeorological models AMBAV and
+// AMBETI for the stations of the climate observations.
+// The files contain all parameters of a station, so the products select the
+// parameters read from the files.
+var DerivedSoil Database = derivedSoil{}
+
+// AvailableDerivedSoilProducts contains a mapping of the derived soil products
+// to the available granularities.
+var AvailableDerivedSoilProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Daily: {
+		dwdTypes.Derived_SoilMoisture,
+		dwdTypes.Derived_Evapotranspiration,
+		dwdTypes.Derived_ClimaticWaterBalance,
+		dwdTypes.ClimateObservation_SoilTemperature,
+	},
+	dwdTypes.Granularity_Monthly: {
+		dwdTypes.Derived_SoilMoisture,
+		dwdTypes.Derived_Evapotranspiration,
+		dwdTypes.Derived_ClimaticWaterBalance,
+		dwdTypes.ClimateObservation_SoilTemperature,
+	},
+}
+
+// derivedSoilParameters selects the parameters of the files belonging to the
+// products using the prefixes of their names.
+var derivedSoilParameters = map[Product]func(parameter string) bool{
+	dwdTypes.Derived_SoilMoisture: func(parameter string) bool {
+		return strings.HasPrefix(parameter, "BF")
+	},
+	dwdTypes.Derived_Evapotranspiration: func(parameter string) bool {
+		return strings.HasPrefix(parameter, "V")
+	},
+	dwdTypes.ClimateObservation_SoilTemperature: func(parameter string) bool {
+		return strings.HasPrefix(parameter, "TS") || parameter == "ZFUMI"
+	},
+}
+
+// derivedSoilPrecipitation contains the labels of the precipitation heights in
+// the climate observations (kl) the climatic water balance is derived from.
+var derivedSoilPrecipitation = map[Granularity]string{
+	dwdTypes.Granularity_Daily:   "RSK",
+	dwdTypes.Granularity_Monthly: "MO_RR",
+}
+
+// derivedSoilFile is a datafile listed in a period folder of a granularity.
+type derivedSoilFile struct {
+	periodFile
+	stationID string
+}
+
+type derivedSoil struct{}
+
+func (derivedSoil) Name() string {
+	return DerivedSoilUrlKey
+}
+
+func (derivedSoil) BaseUrl() string {
+	return DerivedSoilBaseUrl
+}
+
+func (derivedSoil) Products() map[Granularity][]Product {
+	return AvailableDerivedSoilProducts
+}
+
+// DiscoverStations discovers the stations with a datafile in the granularity
+// and describes them using the station catalogue of the daily climate
+// observations.
+// Since the datafiles cover all products, the stations are available for all
+// products of the granularity.
+func (db derivedSoil) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	if !SupportsProduct(db, granularity, product) {
+		return nil, errUnsupportedProduct
+	}
+
+	files, descriptionFiles, err := db.listFiles(granularity, nil)
+	if err != nil {
+		return nil, err
+	}
+	ReleaseFiles(nil, descriptionFiles)
+	stationIDs := make(map[string]bool)
+	for _, file := range files {
+		stationIDs[file.stationID] = true
+	}
+
+	// the products are calculated for the stations of the daily climate
+	// observations (kl), so the stations are described by their catalogue
+	climateStations, err := CachedStations(context.Background(), ClimateObservations.Name(),
+		dwdTypes.Granularity_Daily, dwdTypes.ClimateObservation_StationObservations)
+	if err != nil {
+		return nil, err
+	}
+
+	var stations []v2.Station
+	for _, station := range climateStations {
+		observed := station.SupportedProducts[dwdTypes.ClimateObservation_StationObservations][dwdTypes.Granularity_Daily]
+		if !stationIDs[station.ID] || observed.End.Before(derivedSoilStart) {
+			continue
+		}
+
+		available := observed
+		if available.Start.Before(derivedSoilStart) {
+			available.Start = derivedSoilStart
+		}
+
+		station.SupportedProducts = map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+			product: {
+				granularity: available,
+			},
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, nil
+}
+
+// DownloadFiles downloads the datafiles of the station in the period folders
+// selected by the filter.
+func (db derivedSoil) DownloadFiles(stationID string, product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	files, descriptionFiles, err := db.listFiles(granularity, filter.Periods)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	var l sync.Mutex
+	var dataFiles []DataFile
+
+	for _, file := range files {
+		if file.stationID != stationID {
+			continue
+		}
+		group.Go(func() error {
+			filepath, err := dwd.Download(file.uri)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: file.name, Period: file.period})
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, descriptionFiles)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+func (derivedSoil) OpenDataFile(file DataFile, stationID string, product Product, granularity Granularity, labels []string) (*Archive, error) { //nolint:lll
+	if product == dwdTypes.Derived_ClimaticWaterBalance {
+		return openClimaticWaterBalance(file, stationID, granularity, labels)
+	}
+
+	include, supported := derivedSoilParameters[product]
+	if !supported {
+		return nil, errUnsupportedProduct
+	}
+
+	archive, err := parser.OpenSoilObservations(file.Path, include, labels)
+	if err != nil {
+		return nil, err
+	}
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// openClimaticWaterBalance derives the climatic water balance of the station
+// from the potential evapotranspiration in the datafile and the precipitation
+// observed at the station in the range covered by the datafile.
+func openClimaticWaterBalance(file DataFile, stationID string, granularity Granularity, labels []string) (*Archive, error) { //nolint:lll
+	if len(labels) > 0 && !slices.Contains(labels, parser.SoilParameter_ClimaticWaterBalance) {
+		return &Archive{Period: file.Period}, nil
+	}
+
+	evapotranspiration, err := parser.OpenSoilObservations(file.Path, func(parameter string) bool {
+		return parameter == parser.SoilParameter_PotentialEvapotranspiration
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(evapotranspiration.Metadata) == 0 {
+		return &Archive{Period: file.Period}, nil
+	}
+
+	metadata := evapotranspiration.Metadata[0]
+	precipitation, err := readPrecipitation(stationID, granularity, metadata.ValidFrom, metadata.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := parser.ClimaticWaterBalance(evapotranspiration, precipitation)
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// readPrecipitation reads the precipitation heights observed at the station
+// between start and end from the climate observations (kl).
+// The precipitation of the historical files takes precedence over the one of
+// the recent files, since it has passed the complete quality control.
+func readPrecipitation(stationID string, granularity Granularity, start, end time.Time) (map[time.Time]float64, error) { //nolint:lll
+	label := derivedSoilPrecipitation[granularity]
+	files, descriptions, err := ClimateObservations.DownloadFiles(stationID,
+		dwdTypes.ClimateObservation_StationObservations, granularity, DownloadFilter{Start: start, End: end})
+	if err != nil {
+		return nil, err
+	}
+	defer ReleaseFiles(files, descriptions)
+
+	precipitation := make(map[time.Time]float64)
+	for _, file := range files {
+		archive, err := ClimateObservations.OpenDataFile(file, stationID,
+			dwdTypes.ClimateObservation_StationObservations, granularity, []string{label})
+		if err != nil {
+			return nil, err
+		}
+
+		for dp, err := range archive.Datapoints() {
+			if err != nil {
+				archive.Close()
+				return nil, err
+			}
+			height, valid := dp.Value.(float64)
+			if !valid || height == -999 { //nolint:mnd
+				continue
+			}
+			timestamp := dp.Timestamp.UTC()
+			if _, known := precipitation[timestamp]; known && file.Period != dwdTypes.Period_Historical {
+				continue
+			}
+			precipitation[timestamp] = height
+		}
+		archive.Close()
+	}
+	return precipitation, nil
+}
+
+// listFiles lists the datafiles in the period folders of the granularity and
+// downloads the description files placed in the granularity folder.
+// If no period is supplied, the datafiles of all periods are listed.
+func (db derivedSoil) listFiles(granularity Granularity, periods []Period) ([]derivedSoilFile, [][2]string, error) {
+	granularityUri, err := url.JoinPath(db.BaseUrl(), granularity.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	descriptions, folders, err := readFolder(granularityUri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	periodFiles, err := listPeriodFiles(granularityUri, folders, periods)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []derivedSoilFile
+	for _, file := range periodFiles {
+		stationID, ok := parser.ParseSoilFileName(file.name)
+		if !ok {
+			continue
+		}
+		files = append(files, derivedSoilFile{periodFile: file, stationID: stationID})
+	}
+
+	descriptionFiles, err := downloadDescriptionFiles(granularityUri, descriptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, descriptionFiles, nil
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"slices"
 	"strings"
 	"sync"
 
@@ -21,11 +20,10 @@ var (
 	errStatusNotOK        = errors.New("the response code indicated a unsuccessful request")
 )
 
-func DiscoverStations(databaseUrl string, granularity Granularity, product Product) ([]v2.Station, error) {
-	if !slices.Contains(AvailableClimateObservationProducts[granularity], product) {
-		return nil, errUnsupportedProduct
-	}
-
+// discoverStations discovers the stations offering the product in the
+// granularity using the station lists placed in the period folders of a
+// database organized in folders per granularity and product.
+func discoverStations(databaseUrl string, granularity Granularity, product Product) ([]v2.Station, error) {
 	uri, err := url.JoinPath(databaseUrl, granularity.UrlPart(), product.UrlPart())
 	if err != nil {
 		return nil, err
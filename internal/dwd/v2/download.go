@@ -1,13 +1,14 @@
 package v2
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -15,111 +16,296 @@ import (
 	"microservice/internal/dwd/v2/internal/parser"
 )
 
-var (
-	errUnknownDatabase = errors.New("unknown database")
-)
+// DataFile is a data file downloaded from the OpenData Portal.
+type DataFile struct {
+	// Path contains the local path of the downloaded file.
+	Path string
 
-// DownloadFiles tries to download all available files for the given parameters.
-// It returns the filepaths of the downloaded datafiles and (if availalbe) the
-// description pages for the datasets.
-func DownloadFiles(database, stationID string, product Product, granularity Granularity) (datafiles []string, descriptions [][2]string, err error) { //nolint:lll
-	keys := make([]string, 0, len(Products))
-	for k := range Products {
-		keys = append(keys, k)
-	}
-	if !slices.Contains(keys, database) {
-		return nil, nil, errUnknownDatabase
-	}
+	// Name contains the name of the file on the OpenData Portal.
+	Name string
 
-	if !slices.Contains(Products[database][granularity], product) {
-		return nil, nil, errUnsupportedProduct
-	}
+	// Period contains the period folder the file has been downloaded from.
+	// Files which are not split into periods use [dwdTypes.Period_None].
+	Period Period
+}
+
+// DownloadFilter limits the datafiles downloaded by [Database.DownloadFiles].
+type DownloadFilter struct {
+	// Periods contains the periods the datafiles are downloaded from.
+	// If no period is supplied, the datafiles of all periods are downloaded.
+	Periods []Period
+
+	// Start and End limit the datafiles to the ones which may contain
+	// datapoints between them, as far as the names of the datafiles encode
+	// the range they cover.
+	// A zero start or end leaves the range open on that side.
+	Start, End time.Time
+}
 
-	uri, err := url.JoinPath(Databases[database], granularity.UrlPart(), product.UrlPart())
+// downloadFiles tries to download all available files for the given
+// parameters from a database organized in folders per granularity and
+// product, which are split into the periods of the observations.
+// It returns the downloaded datafiles and (if availalbe) the description pages
+// for the datasets.
+func downloadFiles(databaseUrl, stationID string, product Product, granularity Granularity, filter DownloadFilter) (datafiles []DataFile, descriptions [][2]string, err error) { //nolint:lll
+	uri, err := url.JoinPath(databaseUrl, granularity.UrlPart(), product.UrlPart())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	res, err := http.Get(uri) //nolint:gosec
+	possibleDescriptionFiles, possibleDataFolders, err := readFolder(uri)
 	if err != nil {
 		return nil, nil, err
 	}
-	if res.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("%s: %w", uri, errStatusNotOK)
-	}
 
-	page, err := parser.ReadPage(res.Body)
+	dataFiles := make([]DataFile, 0)
+
+	descriptionFiles, err := downloadDescriptionFiles(uri, possibleDescriptionFiles)
 	if err != nil {
-		return nil, nil, fmt.Errorf("%s: %w", uri, err)
+		return nil, nil, err
 	}
+	defer func() {
+		if err != nil {
+			ReleaseFiles(dataFiles, descriptionFiles)
+		}
+	}()
 
-	possibleDescriptionFiles := parser.ParseFileLinks(page)
-	possibleDataFolders := parser.ParseFolderLinks(page)
-
-	descriptionFiles := make([][2]string, len(possibleDescriptionFiles))
-	dataFiles := make([]string, 0)
+	var group errgroup.Group
+	var l sync.Mutex
 
-	for idx, file := range possibleDescriptionFiles {
-		uri, err := url.JoinPath(uri, file)
+	// walkFolder downloads the datafiles of the station in the folder and
+	// descends into the year folders contained in it, which are used by the
+	// high-resolution products to split up their historical datafiles
+	var walkFolder func(uri string, period Period) error
+	walkFolder = func(uri string, period Period) error {
+		possibleDataFiles, folders, err := readFolder(uri)
 		if err != nil {
-			return nil, nil, err
+			return err
 		}
-		filepath, err := dwd.Download(uri)
-		if err != nil {
-			return nil, nil, err
+
+		for _, folder := range folders {
+			year, isYearFolder := parseYearFolder(folder)
+			if !isYearFolder || !yearOverlaps(year, filter.Start, filter.End) {
+				continue
+			}
+
+			folderUri, err := url.JoinPath(uri, folder)
+			if err != nil {
+				return err
+			}
+			group.Go(func() error {
+				return walkFolder(folderUri, period)
+			})
 		}
 
-		descriptionFiles[idx] = [2]string{file, filepath}
-	}
+		for _, datafile := range possibleDataFiles {
+			name, err := parser.ParseFileName(datafile)
+			if err != nil || name.StationID != stationID {
+				continue
+			}
 
-	var group errgroup.Group
-	var l sync.Mutex
+			if !name.Overlaps(filter.Start, filter.End) {
+				continue
+			}
 
-	for _, folder := range possibleDataFolders {
-		group.Go(func() error {
-			uri, err := url.JoinPath(uri, folder)
+			uri, err := url.JoinPath(uri, datafile)
 			if err != nil {
 				return err
 			}
-			res, err := http.Get(uri) //nolint:gosec
+			filepath, err := dwd.Download(uri)
 			if err != nil {
 				return err
 			}
-			if res.StatusCode != http.StatusOK {
-				return fmt.Errorf("%s: %w", uri, errStatusNotOK)
-			}
 
-			page, err := parser.ReadPage(res.Body)
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: datafile, Period: period})
+			l.Unlock()
+		}
+		return nil
+	}
+
+	for _, folder := range possibleDataFolders {
+		var period Period
+		if err := period.Parse(folder); err != nil {
+			// the folders which are not a period contain no datafiles
+			continue
+		}
+
+		if len(filter.Periods) > 0 && !slices.Contains(filter.Periods, period) {
+			continue
+		}
+
+		folderUri, err := url.JoinPath(uri, folder)
+		if err != nil {
+			return nil, nil, err
+		}
+		group.Go(func() error {
+			return walkFolder(folderUri, period)
+		})
+	}
+
+	if err = group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+// ReleaseFiles releases the downloaded datafiles and description files, which
+// allows the cache to evict them.
+// The files may not be read after releasing them.
+func ReleaseFiles(dataFiles []DataFile, descriptionFiles [][2]string) {
+	for _, dataFile := range dataFiles {
+		if dataFile.Path != "" {
+			dwd.Release(dataFile.Path)
+		}
+	}
+	for _, descriptionFile := range descriptionFiles {
+		if descriptionFile[1] != "" {
+			dwd.Release(descriptionFile[1])
+		}
+	}
+}
+
+// downloadConcurrency limits the number of files which are downloaded or
+// listed in parallel.
+const downloadConcurrency = 8
+
+// periodFile is a file listed in a period folder.
+type periodFile struct {
+	uri, name string
+	period    Period
+}
+
+// listPeriodFiles lists the files in the period folders among the folders of
+// the parent folder.
+// Only the period folders selected by the periods are listed, and if no
+// period is supplied, the files of all periods are listed.
+func listPeriodFiles(parentUri string, folders []string, periods []Period) ([]periodFile, error) {
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	var files []periodFile
+
+	for _, folder := range folders {
+		var period Period
+		if err := period.Parse(folder); err != nil {
+			continue
+		}
+		if len(periods) > 0 && !slices.Contains(periods, period) {
+			continue
+		}
+
+		folderUri, err := url.JoinPath(parentUri, folder)
+		if err != nil {
+			return nil, err
+		}
+		group.Go(func() error {
+			names, _, err := readFolder(folderUri)
 			if err != nil {
-				return fmt.Errorf("%s: %w", uri, err)
+				return err
 			}
 
-			possibleDataFiles := parser.ParseFileLinks(page)
-			for _, datafile := range possibleDataFiles {
-				if !strings.Contains(datafile, stationID) {
-					continue
-				}
-				uri, err := url.JoinPath(uri, datafile)
-				if err != nil {
-					return err
-				}
-				filepath, err := dwd.Download(uri)
+			for _, name := range names {
+				fileUri, err := url.JoinPath(folderUri, name)
 				if err != nil {
 					return err
 				}
 
 				l.Lock()
-				dataFiles = append(dataFiles, filepath)
+				files = append(files, periodFile{uri: fileUri, name: name, period: period})
 				l.Unlock()
 			}
 			return nil
-
 		})
 	}
 
 	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// fileOverlaps reports if a file covering the range between first and last
+// may contain datapoints requested by the filter.
+// The range is widened by a day on both sides, since the files are stamped
+// with the end of the interval they cover.
+func fileOverlaps(first, last time.Time, filter DownloadFilter) bool {
+	if !filter.End.IsZero() && first.After(filter.End.AddDate(0, 0, 1)) {
+		return false
+	}
+	if !filter.Start.IsZero() && last.Before(filter.Start.AddDate(0, 0, -1)) {
+		return false
+	}
+	return true
+}
+
+// parseYearFolder checks if the folder is named after a year and returns the
+// year.
+func parseYearFolder(folder string) (year int, isYearFolder bool) {
+	folder = strings.TrimSuffix(strings.TrimSpace(folder), "/")
+	if len(folder) != 4 { //nolint:mnd
+		return 0, false
+	}
+
+	year, err := strconv.Atoi(folder)
+	if err != nil {
+		return 0, false
+	}
+	return year, true
+}
+
+// yearOverlaps reports if the year overlaps the range between start and end
+// (both inclusive).
+// A zero start or end leaves the range open on that side.
+// The range is widened by a day on both sides, since the datafiles are not
+// necessarily split at midnight UTC.
+func yearOverlaps(year int, start, end time.Time) bool {
+	if !end.IsZero() && year > end.AddDate(0, 0, 1).UTC().Year() {
+		return false
+	}
+	if !start.IsZero() && year < start.AddDate(0, 0, -1).UTC().Year() {
+		return false
+	}
+	return true
+}
+
+// readFolder reads the listing of a folder on the OpenData Portal and returns
+// the names of the files and subfolders contained in it.
+func readFolder(uri string) (files, folders []string, err error) {
+	res, err := http.Get(uri) //nolint:gosec
+	if err != nil {
 		return nil, nil, err
 	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s: %w", uri, errStatusNotOK)
+	}
 
-	return dataFiles, descriptionFiles, nil
+	page, err := parser.ReadPage(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", uri, err)
+	}
+
+	return parser.ParseFileLinks(page), parser.ParseFolderLinks(page), nil
+}
+
+// downloadDescriptionFiles downloads the files in the folder and returns their
+// names together with their local paths.
+func downloadDescriptionFiles(uri string, files []string) ([][2]string, error) {
+	descriptionFiles := make([][2]string, len(files))
+	for idx, file := range files {
+		fileUri, err := url.JoinPath(uri, file)
+		if err != nil {
+			return nil, err
+		}
+		filepath, err := dwd.Download(fileUri)
+		if err != nil {
+			ReleaseFiles(nil, descriptionFiles)
+			return nil, err
+		}
+
+		descriptionFiles[idx] = [2]string{file, filepath}
+	}
+	return descriptionFiles, nil
 }
@@ -0,0 +1,93 @@
+package dwdTypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Aggregation represents a function used to aggregate multiple values of a
+// timeseries into a single value.
+type Aggregation uint8
+
+const (
+	Aggregation_None Aggregation = iota
+	Aggregation_Mean
+	Aggregation_Min
+	Aggregation_Max
+	Aggregation_Sum
+	Aggregation_Count
+	Aggregation_First
+	Aggregation_Last
+)
+
+func (a Aggregation) String() string {
+	switch a {
+	case Aggregation_Mean:
+		return "mean"
+	case Aggregation_Min:
+		return "min"
+	case Aggregation_Max:
+		return "max"
+	case Aggregation_Sum:
+		return "sum"
+	case Aggregation_Count:
+		return "count"
+	case Aggregation_First:
+		return "first"
+	case Aggregation_Last:
+		return "last"
+	default:
+		return ""
+	}
+}
+
+func (a *Aggregation) Parse(src any) error {
+	if v := reflect.ValueOf(src); !v.IsValid() {
+		return errors.New("aggregation may not be <nil>")
+	}
+
+	var aggregation string
+	switch v := src.(type) {
+	case string:
+		aggregation = v
+	case []byte:
+		aggregation = string(v)
+	default:
+		return errors.New("unsupported input type")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(aggregation)) {
+	case Aggregation_Mean.String():
+		*a = Aggregation_Mean
+	case Aggregation_Min.String():
+		*a = Aggregation_Min
+	case Aggregation_Max.String():
+		*a = Aggregation_Max
+	case Aggregation_Sum.String():
+		*a = Aggregation_Sum
+	case Aggregation_Count.String():
+		*a = Aggregation_Count
+	case Aggregation_First.String():
+		*a = Aggregation_First
+	case Aggregation_Last.String():
+		*a = Aggregation_Last
+	default:
+		*a = Aggregation_None
+		return errors.New("unsupported aggregation")
+	}
+	return nil
+}
+
+func (a Aggregation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *Aggregation) UnmarshalJSON(src []byte) error {
+	var aggregation string
+	if err := json.Unmarshal(src, &aggregation); err != nil {
+		return err
+	}
+	return a.Parse(aggregation)
+}
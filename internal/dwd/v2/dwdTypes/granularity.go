@@ -5,13 +5,16 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 )
 
 type Granularity uint8
 
 const (
-	Granularity_None   Granularity = 0
-	Granularity_Annual Granularity = iota
+	Granularity_None        Granularity = 0
+	Granularity_MultiAnnual Granularity = iota
+	Granularity_Annual
+	Granularity_Seasonal
 	Granularity_Monthly
 	Granularity_Daily
 	Granularity_SubDaily
@@ -25,8 +28,12 @@ func (g Granularity) String() string {
 	switch g {
 	case Granularity_None:
 		return ""
+	case Granularity_MultiAnnual:
+		return "multiAnnual"
 	case Granularity_Annual:
 		return "annual"
+	case Granularity_Seasonal:
+		return "seasonal"
 	case Granularity_Monthly:
 		return "monthly"
 	case Granularity_Daily:
@@ -48,8 +55,12 @@ func (g Granularity) String() string {
 
 func (g Granularity) UrlPart() string {
 	switch g {
+	case Granularity_MultiAnnual:
+		return "multi_annual"
 	case Granularity_Annual:
 		return g.String()
+	case Granularity_Seasonal:
+		return g.String()
 	case Granularity_Monthly:
 		return g.String()
 	case Granularity_Daily:
@@ -69,6 +80,45 @@ func (g Granularity) UrlPart() string {
 	}
 }
 
+// Truncate returns the start of the interval of the granularity which
+// contains the time.
+// The intervals are aligned to UTC.
+// Since the sub-daily observations are taken at irregular times and the
+// reference periods of the multi-annual means are not aligned to a fixed
+// interval, the time is returned unchanged for [Granularity_SubDaily],
+// [Granularity_MultiAnnual] and [Granularity_None].
+func (g Granularity) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch g {
+	case Granularity_Annual:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	case Granularity_Seasonal:
+		// the meteorological seasons start in December, March, June and
+		// September, so that the winter starts in the previous year
+		return time.Date(t.Year(), t.Month()-t.Month()%3, 1, 0, 0, 0, 0, time.UTC) //nolint:mnd
+	case Granularity_Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case Granularity_Daily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case Granularity_Hourly:
+		return t.Truncate(time.Hour)
+	case Granularity_Every10Mins:
+		return t.Truncate(10 * time.Minute) //nolint:mnd
+	case Granularity_Every5Mins:
+		return t.Truncate(5 * time.Minute) //nolint:mnd
+	case Granularity_EveryMinute:
+		return t.Truncate(time.Minute)
+	default:
+		return t
+	}
+}
+
+// CoarserThan reports if the intervals of the granularity are longer than the
+// intervals of the other granularity.
+func (g Granularity) CoarserThan(other Granularity) bool {
+	return g != Granularity_None && g < other
+}
+
 func (g *Granularity) Parse(src any) error {
 	if v := reflect.ValueOf(src); !v.IsValid() {
 		return errors.New("granularity may not be <nil>")
@@ -87,8 +137,12 @@ func (g *Granularity) Parse(src any) error {
 
 func (g *Granularity) parseString(s string) error {
 	switch strings.TrimSpace(s) {
+	case Granularity_MultiAnnual.String(), Granularity_MultiAnnual.UrlPart():
+		*g = Granularity_MultiAnnual
 	case Granularity_Annual.String(), Granularity_Annual.UrlPart():
 		*g = Granularity_Annual
+	case Granularity_Seasonal.String(), Granularity_Seasonal.UrlPart():
+		*g = Granularity_Seasonal
 	case Granularity_Monthly.String(), Granularity_Monthly.UrlPart():
 		*g = Granularity_Monthly
 	case Granularity_Daily.String(), Granularity_Daily.UrlPart():
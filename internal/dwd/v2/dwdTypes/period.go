@@ -0,0 +1,92 @@
+package dwdTypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Period represents the folders the observations of a product are split into
+// on the OpenData Portal.
+// The periods are ordered by their preference, as the historical observations
+// have passed the complete quality control while the recent and current
+// observations have not.
+type Period uint8
+
+const (
+	Period_None Period = iota
+	Period_Historical
+	Period_Recent
+	Period_Now
+)
+
+// Periods contains all periods ordered by their preference.
+var Periods = []Period{Period_Historical, Period_Recent, Period_Now}
+
+func (p Period) String() string {
+	switch p {
+	case Period_Historical:
+		return "historical"
+	case Period_Recent:
+		return "recent"
+	case Period_Now:
+		return "now"
+	default:
+		return ""
+	}
+}
+
+func (p Period) UrlPart() string {
+	if p == Period_None {
+		return ""
+	}
+	return p.String() + "/"
+}
+
+// PreferredOver reports if observations of the period are preferred over the
+// observations of the other period.
+func (p Period) PreferredOver(other Period) bool {
+	return p != Period_None && (other == Period_None || p < other)
+}
+
+func (p *Period) Parse(src any) error {
+	if v := reflect.ValueOf(src); !v.IsValid() {
+		return errors.New("period may not be <nil>")
+	}
+
+	var period string
+	switch v := src.(type) {
+	case string:
+		period = v
+	case []byte:
+		period = string(v)
+	default:
+		return errors.New("unsupported input type")
+	}
+
+	switch strings.TrimSuffix(strings.TrimSpace(period), "/") {
+	case Period_Historical.String():
+		*p = Period_Historical
+	case Period_Recent.String():
+		*p = Period_Recent
+	case Period_Now.String():
+		*p = Period_Now
+	default:
+		*p = Period_None
+		return errors.New("unsupported period")
+	}
+	return nil
+}
+
+func (p Period) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Period) UnmarshalJSON(src []byte) error {
+	var s string
+	if err := json.Unmarshal(src, &s); err != nil {
+		return err
+	}
+	return p.Parse(s)
+}
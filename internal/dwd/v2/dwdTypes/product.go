@@ -31,6 +31,23 @@ const (
 	ClimateObservation_WeatherPhenomena
 	ClimateObservation_WindSpeeds
 	ClimateObservation_WindSynopsis
+	ClimateObservation_StationObservations
+	Forecast_MosmixL
+	Forecast_MosmixS
+	Grid_Radolan
+	// AirTemperatureMean is offered by the grids, the CLIMAT observations and
+	// the regional averages and is therefore not prefixed by a database.
+	AirTemperatureMean
+	Grid_PotentialEvapotranspiration
+	Phenology_Crops
+	Phenology_Farming
+	Phenology_Fruit
+	Phenology_Vine
+	Phenology_Wild
+	RegionalAverage_SunshineDuration
+	Derived_SoilMoisture
+	Derived_Evapotranspiration
+	Derived_ClimaticWaterBalance
 )
 
 func (p Product) String() string {
@@ -79,6 +96,36 @@ func (p Product) String() string {
 		return "windSpeeds"
 	case ClimateObservation_WindSynopsis:
 		return "windSynopsis"
+	case ClimateObservation_StationObservations:
+		return "stationObservations"
+	case Forecast_MosmixL:
+		return "mosmixL"
+	case Forecast_MosmixS:
+		return "mosmixS"
+	case Grid_Radolan:
+		return "radolan"
+	case AirTemperatureMean:
+		return "airTemperatureMean"
+	case Grid_PotentialEvapotranspiration:
+		return "potentialEvapotranspiration"
+	case Phenology_Crops:
+		return "phenologyCrops"
+	case Phenology_Farming:
+		return "phenologyFarming"
+	case Phenology_Fruit:
+		return "phenologyFruit"
+	case Phenology_Vine:
+		return "phenologyVine"
+	case Phenology_Wild:
+		return "phenologyWild"
+	case RegionalAverage_SunshineDuration:
+		return "sunshineDuration"
+	case Derived_SoilMoisture:
+		return "soilMoisture"
+	case Derived_Evapotranspiration:
+		return "evapotranspiration"
+	case Derived_ClimaticWaterBalance:
+		return "climaticWaterBalance"
 	default:
 		return ""
 	}
@@ -116,6 +163,32 @@ func (p Product) UrlPart() string {
 		return "wind"
 	case ClimateObservation_WindSynopsis:
 		return "wind_synop"
+	case ClimateObservation_StationObservations:
+		return "kl"
+	case Forecast_MosmixL:
+		return "MOSMIX_L"
+	case Forecast_MosmixS:
+		return "MOSMIX_S"
+	case Grid_Radolan:
+		return "radolan"
+	case AirTemperatureMean:
+		return "air_temperature_mean"
+	case Grid_PotentialEvapotranspiration:
+		return "evapo_p"
+	case Phenology_Crops:
+		return "crops"
+	case Phenology_Farming:
+		return "farming"
+	case Phenology_Fruit:
+		return "fruit"
+	case Phenology_Vine:
+		return "vine"
+	case Phenology_Wild:
+		return "wild"
+	case RegionalAverage_SunshineDuration:
+		return "sunshine_duration"
+	case Derived_SoilMoisture:
+		return "soil_moisture"
 	default:
 		return p.String()
 	}
@@ -180,6 +253,36 @@ func (p *Product) Parse(src any) error {
 		*p = ClimateObservation_WindSpeeds
 	case ClimateObservation_WindSynopsis.String(), ClimateObservation_WindSynopsis.UrlPart():
 		*p = ClimateObservation_WindSynopsis
+	case ClimateObservation_StationObservations.String(), ClimateObservation_StationObservations.UrlPart():
+		*p = ClimateObservation_StationObservations
+	case Forecast_MosmixL.String(), Forecast_MosmixL.UrlPart():
+		*p = Forecast_MosmixL
+	case Forecast_MosmixS.String(), Forecast_MosmixS.UrlPart():
+		*p = Forecast_MosmixS
+	case Grid_Radolan.String():
+		*p = Grid_Radolan
+	case AirTemperatureMean.String(), AirTemperatureMean.UrlPart():
+		*p = AirTemperatureMean
+	case Grid_PotentialEvapotranspiration.String(), Grid_PotentialEvapotranspiration.UrlPart():
+		*p = Grid_PotentialEvapotranspiration
+	case Phenology_Crops.String(), Phenology_Crops.UrlPart():
+		*p = Phenology_Crops
+	case Phenology_Farming.String(), Phenology_Farming.UrlPart():
+		*p = Phenology_Farming
+	case Phenology_Fruit.String(), Phenology_Fruit.UrlPart():
+		*p = Phenology_Fruit
+	case Phenology_Vine.String(), Phenology_Vine.UrlPart():
+		*p = Phenology_Vine
+	case Phenology_Wild.String(), Phenology_Wild.UrlPart():
+		*p = Phenology_Wild
+	case RegionalAverage_SunshineDuration.String(), RegionalAverage_SunshineDuration.UrlPart():
+		*p = RegionalAverage_SunshineDuration
+	case Derived_SoilMoisture.String(), Derived_SoilMoisture.UrlPart():
+		*p = Derived_SoilMoisture
+	case Derived_Evapotranspiration.String(), Derived_Evapotranspiration.UrlPart():
+		*p = Derived_Evapotranspiration
+	case Derived_ClimaticWaterBalance.String(), Derived_ClimaticWaterBalance.UrlPart():
+		*p = Derived_ClimaticWaterBalance
 	default:
 		return errors.New("unsupported product")
 	}
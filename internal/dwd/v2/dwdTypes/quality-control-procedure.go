@@ -54,12 +54,9 @@ func (q *QualityControlProcedure) Parse(src any) error {
 	case []byte:
 		procedure = string(v)
 	case int:
-		if (0 < v && v <= 3) || v == 5 || (v <= 7 && v <= 10) {
-			*q = QualityControlProcedure(v)
-			return nil
-		}
-
-		return errors.New("int not mapped to control procedure")
+		return q.parseLevel(int64(v))
+	case int64:
+		return q.parseLevel(v)
 	default:
 		return errors.New("unsupported input type")
 	}
@@ -87,6 +84,19 @@ func (q *QualityControlProcedure) Parse(src any) error {
 	return nil
 }
 
+// parseLevel sets the procedure from the quality level (QN) used in the data
+// files of the DWD.
+func (q *QualityControlProcedure) parseLevel(level int64) error {
+	switch QualityControlProcedure(level) {
+	case QCP_FormalControl, QCP_IndividualCriteria, QCP_Automatic, QCP_HistoricSubjective,
+		QCP_SecondaryControl, QCP_OutOfRoutine, QCP_SingleParameterCorrection, QCP_Finished:
+		*q = QualityControlProcedure(level)
+		return nil
+	default:
+		return errors.New("int not mapped to control procedure")
+	}
+}
+
 func (q QualityControlProcedure) MarshalJSON() ([]byte, error) {
 	return json.Marshal(q.String())
 }
@@ -0,0 +1,82 @@
+package dwdTypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Severity represents the severity of a weather warning as given in the CAP
+// alerts.
+// The severities are ordered, so that a warning is at least as severe as
+// another one if its severity is not smaller.
+type Severity uint8
+
+const (
+	Severity_Unknown Severity = iota
+	Severity_Minor
+	Severity_Moderate
+	Severity_Severe
+	Severity_Extreme
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Severity_Minor:
+		return "minor"
+	case Severity_Moderate:
+		return "moderate"
+	case Severity_Severe:
+		return "severe"
+	case Severity_Extreme:
+		return "extreme"
+	default:
+		return "unknown"
+	}
+}
+
+func (s *Severity) Parse(src any) error {
+	if v := reflect.ValueOf(src); !v.IsValid() {
+		return errors.New("severity may not be <nil>")
+	}
+
+	var severity string
+	switch v := src.(type) {
+	case string:
+		severity = v
+	case []byte:
+		severity = string(v)
+	default:
+		return errors.New("unsupported input type")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case Severity_Unknown.String():
+		*s = Severity_Unknown
+	case Severity_Minor.String():
+		*s = Severity_Minor
+	case Severity_Moderate.String():
+		*s = Severity_Moderate
+	case Severity_Severe.String():
+		*s = Severity_Severe
+	case Severity_Extreme.String():
+		*s = Severity_Extreme
+	default:
+		*s = Severity_Unknown
+		return errors.New("unsupported severity")
+	}
+	return nil
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(src []byte) error {
+	var severity string
+	if err := json.Unmarshal(src, &severity); err != nil {
+		return err
+	}
+	return s.Parse(severity)
+}
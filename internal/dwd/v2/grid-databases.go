@@ -0,0 +1,81 @@
+package v2
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/twpayne/go-geom"
+
+	"microservice/internal/dwd/v2/internal/parser"
+)
+
+// ErrAreaOutsideGrid is returned if an area does not cover any cell of a
+// grid.
+var ErrAreaOutsideGrid = parser.ErrAreaOutsideGrid
+
+// GridArea is the area a timeseries is extracted from a grid for.
+type GridArea = parser.GridArea
+
+// NewGridArea creates the area from a point, polygon or multipolygon given in
+// WGS84.
+func NewGridArea(geometry geom.T) (GridArea, error) {
+	return parser.NewGridArea(geometry)
+}
+
+// GridDatabase is a gridded dataset offered on the OpenData Portal.
+// Instead of stations, the timeseries of a grid database are extracted for an
+// area from the grids covering the requested range.
+type GridDatabase interface {
+	// Name returns the key used to select the database.
+	Name() string
+
+	// BaseUrl returns the url of the database on the OpenData Portal.
+	// It is also used to check if the database is reachable.
+	BaseUrl() string
+
+	// Products returns the products available in each of the granularities.
+	Products() map[Granularity][]Product
+
+	// DownloadGrids downloads the files containing the grids of the product
+	// in the granularity which may contain data between the start and end of
+	// the filter and (if available) the description files of the product.
+	DownloadGrids(product Product, granularity Granularity, filter DownloadFilter) (datafiles []DataFile, descriptions [][2]string, err error) //nolint:lll
+
+	// OpenGrid extracts the timeseries of the area from a file downloaded by
+	// DownloadGrids.
+	// If labels are supplied, only the parameters with these labels are read.
+	// Areas which are not covered by the grids are rejected with
+	// [ErrAreaOutsideGrid].
+	OpenGrid(file DataFile, product Product, granularity Granularity, area GridArea, labels []string) (*Archive, error) //nolint:lll
+}
+
+// gridDatabases contains the grid databases offered by the service mapped to
+// their names.
+var gridDatabases = map[string]GridDatabase{
+	GridsGermanyUrlKey: GridsGermany,
+	RadolanUrlKey:      Radolan,
+}
+
+// LookupGridDatabase returns the grid database with the name.
+func LookupGridDatabase(name string) (GridDatabase, bool) {
+	database, found := gridDatabases[name]
+	return database, found
+}
+
+// GridDatabases returns all grid databases offered by the service ordered by
+// their names.
+func GridDatabases() []GridDatabase {
+	names := slices.Sorted(maps.Keys(gridDatabases))
+
+	result := make([]GridDatabase, len(names))
+	for idx, name := range names {
+		result[idx] = gridDatabases[name]
+	}
+	return result
+}
+
+// SupportsGridProduct checks if the grid database offers the product in the
+// granularity.
+func SupportsGridProduct(database GridDatabase, granularity Granularity, product Product) bool {
+	return slices.Contains(database.Products()[granularity], product)
+}
@@ -0,0 +1,252 @@
+package v2
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	GridsGermanyUrlKey  = "gridsGermany"
+	GridsGermanyBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/grids_germany/"
+)
+
+// gridsGermanyAnnualSuffix is appended to the year in the names of the annual
+// grids, which share the numbering of the months and seasons.
+const gridsGermanyAnnualSuffix = 17
+
+// gridsGermanyFileNamePattern matches the names of the grids, which end with
+// the year and (except for some annual grids) the month, season or year
+// number, e.g. grids_germany_monthly_air_temp_mean_202301.asc.gz.
+var gridsGermanyFileNamePattern = regexp.MustCompile(`(?i)^grids_germany_[a-z_]+?_(\d{4})(\d{2})?\.asc\.gz$`)
+
+var errMalformedGridName = errors.New("malformed grid name")
+
+// GridsGermany contains the gridded monthly and annual climate fields of
+// Germany with a resolution of 1 km.
+// The grids are published as gzip compressed ESRI ASCII grids in the third
+// Gauss-Krüger zone, which are split into folders per month for the monthly
+// grids.
+var GridsGermany GridDatabase = gridsGermany{}
+
+// AvailableGridsGermanyProducts contains a mapping of the gridded products to
+// the available granularities.
+var AvailableGridsGermanyProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Monthly: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.Grid_PotentialEvapotranspiration,
+	},
+	dwdTypes.Granularity_Annual: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.Grid_PotentialEvapotranspiration,
+	},
+}
+
+// gridsGermanyValues describes the values of the gridded products as given
+// in the descriptions of the products, since the grids contain no metadata.
+// The descriptions are only published as PDF documents, so the units and
+// factors are maintained here instead of being read from the descriptions.
+var gridsGermanyValues = map[Product]parser.GridValue{
+	dwdTypes.AirTemperatureMean: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.AirTemperatureMean.String(),
+			Description: "Mittel der Lufttemperatur in 2 m Höhe",
+			Unit:        "°C",
+		},
+		// the temperatures are stored in 1/10 °C
+		Factor: 0.1,
+	},
+	dwdTypes.ClimateObservation_Precipitation: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.ClimateObservation_Precipitation.String(),
+			Description: "Niederschlagshöhe",
+			Unit:        "mm",
+		},
+		Factor: 1,
+	},
+	dwdTypes.Grid_PotentialEvapotranspiration: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.Grid_PotentialEvapotranspiration.String(),
+			Description: "Potentielle Evapotranspiration über Gras",
+			Unit:        "mm",
+		},
+		Factor: 1,
+	},
+}
+
+type gridsGermany struct{}
+
+func (gridsGermany) Name() string {
+	return GridsGermanyUrlKey
+}
+
+func (gridsGermany) BaseUrl() string {
+	return GridsGermanyBaseUrl
+}
+
+func (gridsGermany) Products() map[Granularity][]Product {
+	return AvailableGridsGermanyProducts
+}
+
+// DownloadGrids downloads the grids of the product covering the range of the
+// filter.
+// The grids are not split into periods, so the periods of the filter are
+// ignored.
+func (db gridsGermany) DownloadGrids(product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsGridProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	productUri, err := url.JoinPath(db.BaseUrl(), granularity.UrlPart(), product.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, folders, err := readFolder(productUri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the product folder contains the descriptions next to the grids
+	var descriptions []string
+	for _, file := range files {
+		if !gridsGermanyFileNamePattern.MatchString(file) {
+			descriptions = append(descriptions, file)
+		}
+	}
+
+	// the monthly grids are split into folders per month (e.g. 01_Jan), which
+	// are listed before downloading any grid, so that a failing listing does
+	// not leave downloaded files behind
+	gridUris, err := selectGrids(productUri, files, granularity, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, folder := range folders {
+		folderUri, err := url.JoinPath(productUri, folder)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files, _, err := readFolder(folderUri)
+		if err != nil {
+			return nil, nil, err
+		}
+		uris, err := selectGrids(folderUri, files, granularity, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		gridUris = append(gridUris, uris...)
+	}
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	var dataFiles []DataFile
+	for _, fileUri := range gridUris {
+		group.Go(func() error {
+			filepath, err := dwd.Download(fileUri)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: path.Base(fileUri)})
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, nil)
+		return nil, nil, err
+	}
+
+	descriptionFiles, err := downloadDescriptionFiles(productUri, descriptions)
+	if err != nil {
+		ReleaseFiles(dataFiles, nil)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+// selectGrids returns the uris of the grids in the folder which cover the
+// range of the filter.
+func selectGrids(uri string, files []string, granularity Granularity, filter DownloadFilter) ([]string, error) {
+	var uris []string
+	for _, file := range files {
+		interval, err := parseGridsGermanyName(file, granularity)
+		if err != nil {
+			continue
+		}
+
+		if !fileOverlaps(interval.Start, interval.End, filter) {
+			continue
+		}
+
+		fileUri, err := url.JoinPath(uri, file)
+		if err != nil {
+			return nil, err
+		}
+		uris = append(uris, fileUri)
+	}
+	return uris, nil
+}
+
+func (gridsGermany) OpenGrid(file DataFile, product Product, granularity Granularity, area GridArea, labels []string) (*Archive, error) { //nolint:lll
+	value := gridsGermanyValues[product]
+	if len(labels) > 0 && !slices.Contains(labels, value.Metadata.Name) {
+		return &Archive{}, nil
+	}
+
+	interval, err := parseGridsGermanyName(file.Name, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.OpenAsciiGrid(file.Path, area, value, interval)
+}
+
+// parseGridsGermanyName parses the interval covered by the grid from its name.
+// The end of the interval is the last day covered by the grid.
+// Grids of other granularities (e.g. the seasonal grids) are rejected with an
+// error.
+func parseGridsGermanyName(name string, granularity Granularity) (interval v2.DateTimeRange, err error) {
+	match := gridsGermanyFileNamePattern.FindStringSubmatch(path.Base(name))
+	if match == nil {
+		return interval, errMalformedGridName
+	}
+
+	year, _ := strconv.Atoi(match[1])
+	number := 0
+	if match[2] != "" {
+		number, _ = strconv.Atoi(match[2])
+	}
+
+	switch {
+	case granularity == dwdTypes.Granularity_Monthly && number >= 1 && number <= 12:
+		interval.Start = time.Date(year, time.Month(number), 1, 0, 0, 0, 0, time.UTC)
+		interval.End = interval.Start.AddDate(0, 1, -1)
+	case granularity == dwdTypes.Granularity_Annual && (number == 0 || number == gridsGermanyAnnualSuffix):
+		interval.Start = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		interval.End = interval.Start.AddDate(1, 0, -1)
+	default:
+		return interval, errMalformedGridName
+	}
+
+	return interval, nil
+}
@@ -0,0 +1,100 @@
+package v2
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+func TestParseGridsGermanyName(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		granularity Granularity
+		start, end  time.Time
+		err         bool
+	}{
+		{
+			name:        "grids_germany_monthly_air_temp_mean_202301.asc.gz",
+			granularity: dwdTypes.Granularity_Monthly,
+			start:       date(2023, 1, 1), end: date(2023, 1, 31),
+		},
+		{
+			name:        "01_Jan/grids_germany_monthly_precipitation_202402.asc.gz",
+			granularity: dwdTypes.Granularity_Monthly,
+			start:       date(2024, 2, 1), end: date(2024, 2, 29),
+		},
+		{
+			name:        "grids_germany_monthly_evapo_p_202312.asc.gz",
+			granularity: dwdTypes.Granularity_Monthly,
+			start:       date(2023, 12, 1), end: date(2023, 12, 31),
+		},
+		{
+			// the annual grids share the numbering of the months and seasons
+			name:        "grids_germany_annual_air_temp_mean_202317.asc.gz",
+			granularity: dwdTypes.Granularity_Annual,
+			start:       date(2023, 1, 1), end: date(2023, 12, 31),
+		},
+		{
+			name:        "GRIDS_GERMANY_ANNUAL_PRECIPITATION_2023.ASC.GZ",
+			granularity: dwdTypes.Granularity_Annual,
+			start:       date(2023, 1, 1), end: date(2023, 12, 31),
+		},
+		{name: "grids_germany_seasonal_air_temp_mean_202313.asc.gz", granularity: dwdTypes.Granularity_Monthly, err: true},
+		{name: "grids_germany_annual_air_temp_mean_202313.asc.gz", granularity: dwdTypes.Granularity_Annual, err: true},
+		{name: "grids_germany_annual_air_temp_mean_202317.asc.gz", granularity: dwdTypes.Granularity_Monthly, err: true},
+		{name: "grids_germany_monthly_air_temp_mean_2023.asc.gz", granularity: dwdTypes.Granularity_Monthly, err: true},
+		{name: "DESCRIPTION_gridsgermany_monthly_air_temperature_mean_en.pdf", granularity: dwdTypes.Granularity_Monthly, err: true}, //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval, err := parseGridsGermanyName(tt.name, tt.granularity)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", interval)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !interval.Start.Equal(tt.start) || !interval.End.Equal(tt.end) {
+				t.Errorf("expected %s - %s, got %s - %s", tt.start, tt.end, interval.Start, interval.End)
+			}
+		})
+	}
+}
+
+func TestSelectGrids(t *testing.T) {
+	files := []string{
+		"DESCRIPTION_gridsgermany_monthly_air_temperature_mean_en.pdf",
+		"grids_germany_monthly_air_temp_mean_202211.asc.gz",
+		"grids_germany_monthly_air_temp_mean_202212.asc.gz",
+		"grids_germany_monthly_air_temp_mean_202301.asc.gz",
+		"grids_germany_monthly_air_temp_mean_202303.asc.gz",
+	}
+	filter := DownloadFilter{
+		Start: time.Date(2022, 12, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	uri := "https://example.com/monthly/air_temperature_mean/"
+	uris, err := selectGrids(uri, files, dwdTypes.Granularity_Monthly, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		uri + "grids_germany_monthly_air_temp_mean_202212.asc.gz",
+		uri + "grids_germany_monthly_air_temp_mean_202301.asc.gz",
+	}
+	if !slices.Equal(uris, expected) {
+		t.Errorf("expected %v, got %v", expected, uris)
+	}
+}
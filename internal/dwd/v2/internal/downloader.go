@@ -1,46 +1,147 @@
 package internal
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/gabriel-vasile/mimetype"
+	"microservice/internal"
 )
 
-const filenamePattern = "dwd-proxy-*%s"
+const (
+	cacheMetadataSuffix  = ".json"
+	cacheTemporaryPrefix = "download-*"
+	cacheDirectoryMode   = 0o750
+)
 
 var errStatusNot200 = errors.New("the remote server did not indicate a successful request")
 
-func Download(uri string) (filepath string, err error) {
-	res, err := http.Get(uri) //nolint:gosec
+// entryLocks contains a mutex for every cache entry which is currently
+// downloaded to prevent concurrent downloads of the same url.
+// The mutexes are removed once no download of their entry is running anymore.
+var (
+	entryLocks     = make(map[string]*entryLock)
+	entryLocksLock sync.Mutex
+)
+
+// entryLock is the mutex of a cache entry together with the number of
+// downloads waiting for or holding it.
+type entryLock struct {
+	sync.Mutex
+	users int
+}
+
+// evictionLock prevents multiple evictions from running at the same time and
+// guards the pins of the cache entries.
+var evictionLock sync.Mutex
+
+// pins counts the users of every cache entry.
+// Pinned entries have been returned by [Download] but not yet been released
+// and are never evicted.
+var pins = make(map[string]int)
+
+// cacheEntry contains the validators which are sent to the remote server to
+// check if the cached file is still up to date.
+// Files without validators are used until they are older than the configured
+// maximum age, which is checked using the time they have been fetched at.
+type cacheEntry struct {
+	Url          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// validated reports if the entry contains validators.
+func (e cacheEntry) validated() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+// Download returns the path to a local copy of the file behind the uri.
+//
+// Files are stored in the configured cache directory and are named after the
+// hash of their url.
+// If a cached copy exists, it is validated with a conditional request and only
+// downloaded again if the remote file changed.
+// Cached copies without validators are used without a request until they
+// exceed the configured maximum age.
+// The returned file is owned by the cache and may not be modified or removed.
+// It is pinned in the cache until it is released using [Release].
+func Download(uri string) (localPath string, err error) {
+	directory := internal.Configuration().GetString(internal.ConfigKey_Cache_Directory)
+	if err := os.MkdirAll(directory, cacheDirectoryMode); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(uri))
+	dataPath := filepath.Join(directory, hex.EncodeToString(hash[:]))
+	metadataPath := dataPath + cacheMetadataSuffix
+
+	unlock := lockEntry(dataPath)
+	defer unlock()
+
+	// the entry is pinned before it is validated, so a concurrent eviction
+	// cannot remove the cached copy while it is being revalidated
+	pin(dataPath)
+	defer func() {
+		if err != nil {
+			Release(dataPath)
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
 	if err != nil {
 		return "", err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return "", errStatusNot200
+	entry, cached := readCacheEntry(dataPath, metadataPath)
+	maxAge := internal.Configuration().GetDuration(internal.ConfigKey_Cache_MaxAge)
+	if cached && !entry.validated() && time.Since(entry.Fetched) < maxAge {
+		return dataPath, touch(dataPath)
+	}
+	if cached {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
 	}
 
-	mime := mimetype.Lookup(res.Header.Get("Content-Type"))
-	if mime == nil {
-		mime = &mimetype.MIME{}
+	res, err := http.DefaultClient.Do(req) //nolint:gosec
+	if err != nil {
+		return "", err
 	}
+	defer res.Body.Close()
 
-	filename := fmt.Sprintf(filenamePattern, mime.Extension())
+	switch {
+	case cached && res.StatusCode == http.StatusNotModified:
+		return dataPath, touch(dataPath)
+	case res.StatusCode != http.StatusOK:
+		return "", errStatusNot200
+	}
 
-	f, err := os.CreateTemp("", filename)
+	f, err := os.CreateTemp(directory, cacheTemporaryPrefix)
 	if err != nil {
 		return "", err
 	}
+	defer os.Remove(f.Name()) //nolint:errcheck
 
 	if _, err := io.Copy(f, res.Body); err != nil {
+		_ = f.Close()
 		return "", err
 	}
 
 	if err := f.Sync(); err != nil {
+		_ = f.Close()
 		return "", err
 	}
 
@@ -48,5 +149,155 @@ func Download(uri string) (filepath string, err error) {
 		return "", err
 	}
 
-	return f.Name(), nil
+	if err := os.Rename(f.Name(), dataPath); err != nil {
+		return "", err
+	}
+
+	entry = cacheEntry{
+		Url:          uri,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	metadata, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(metadataPath, metadata, 0o600); err != nil {
+		return "", err
+	}
+
+	evict(directory, internal.Configuration().GetSizeInBytes(internal.ConfigKey_Cache_MaxSize))
+
+	return dataPath, nil
+}
+
+// lockEntry locks the mutex of the cache entry and returns the function
+// unlocking it again.
+func lockEntry(path string) (unlock func()) {
+	entryLocksLock.Lock()
+	lock, found := entryLocks[path]
+	if !found {
+		lock = &entryLock{}
+		entryLocks[path] = lock
+	}
+	lock.users++
+	entryLocksLock.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		entryLocksLock.Lock()
+		defer entryLocksLock.Unlock()
+		lock.users--
+		if lock.users == 0 {
+			delete(entryLocks, path)
+		}
+	}
+}
+
+// touch marks the cache entry as recently used.
+func touch(path string) error {
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+// pin marks the cache entry as used.
+func pin(path string) {
+	evictionLock.Lock()
+	defer evictionLock.Unlock()
+	pins[path]++
+}
+
+// Release releases a file returned by [Download], allowing it to be evicted
+// once all users released it.
+func Release(path string) {
+	evictionLock.Lock()
+	defer evictionLock.Unlock()
+	if pins[path] <= 1 {
+		delete(pins, path)
+		return
+	}
+	pins[path]--
+}
+
+// readCacheEntry reads the validators of a cached file.
+// If either the file or its metadata are missing, the entry is reported as not
+// cached.
+func readCacheEntry(dataPath, metadataPath string) (entry cacheEntry, cached bool) {
+	if _, err := os.Stat(dataPath); err != nil {
+		return cacheEntry{}, false
+	}
+
+	metadata, err := os.ReadFile(metadataPath) //nolint:gosec
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	if err := json.Unmarshal(metadata, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// evict removes the least recently used files from the cache directory until
+// the size of the cached files is below the limit.
+// Pinned files are never removed since they are still in use.
+func evict(directory string, limit uint) {
+	if limit == 0 {
+		return
+	}
+
+	evictionLock.Lock()
+	defer evictionLock.Unlock()
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return
+	}
+
+	type cachedFile struct {
+		path    string
+		size    int64
+		lastUse time.Time
+	}
+
+	var files []cachedFile
+	var totalSize int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, cacheMetadataSuffix) ||
+			strings.HasPrefix(name, strings.TrimSuffix(cacheTemporaryPrefix, "*")) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, cachedFile{path: filepath.Join(directory, name), size: info.Size(), lastUse: info.ModTime()})
+		totalSize += info.Size()
+	}
+
+	slices.SortFunc(files, func(a, b cachedFile) int {
+		return a.lastUse.Compare(b.lastUse)
+	})
+
+	for _, file := range files {
+		if totalSize <= int64(limit) { //nolint:gosec
+			return
+		}
+		if pins[file.path] > 0 {
+			continue
+		}
+
+		if err := os.Remove(file.path); err != nil {
+			continue
+		}
+		_ = os.Remove(file.path + cacheMetadataSuffix)
+		totalSize -= file.size
+	}
 }
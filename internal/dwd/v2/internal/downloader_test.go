@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microservice/internal"
+)
+
+// configureCache points the cache at a temporary directory with the maximum
+// size and age.
+func configureCache(t *testing.T, maxSize string, maxAge time.Duration) string {
+	t.Helper()
+
+	if err := internal.ParseConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	directory := t.TempDir()
+	internal.Configuration().Set(internal.ConfigKey_Cache_Directory, directory)
+	internal.Configuration().Set(internal.ConfigKey_Cache_MaxSize, maxSize)
+	internal.Configuration().Set(internal.ConfigKey_Cache_MaxAge, maxAge)
+	return directory
+}
+
+// testServer serves the path of each request as body and counts the bodies
+// it has sent.
+// If etags is set, the responses carry an ETag and matching conditional
+// requests are answered with 304.
+func testServer(t *testing.T, etags bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var served atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if etags {
+			etag := `"` + r.URL.Path + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		}
+		served.Add(1)
+		_, _ = w.Write([]byte(strings.Repeat("x", 10) + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+	return server, &served
+}
+
+func TestDownloadRevalidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		etags  bool
+		maxAge time.Duration
+		served int32
+	}{
+		{name: "not modified", etags: true, maxAge: time.Hour, served: 1},
+		{name: "no validators within maximum age", etags: false, maxAge: time.Hour, served: 1},
+		{name: "no validators after maximum age", etags: false, maxAge: 0, served: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configureCache(t, "1MB", tt.maxAge)
+			server, served := testServer(t, tt.etags)
+
+			for range 2 {
+				path, err := Download(server.URL + "/file")
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := os.ReadFile(path) //nolint:gosec
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasSuffix(string(content), "/file") {
+					t.Errorf("unexpected content %q", content)
+				}
+				Release(path)
+			}
+
+			if got := served.Load(); got != tt.served {
+				t.Errorf("expected %d full downloads, got %d", tt.served, got)
+			}
+		})
+	}
+}
+
+func TestDownloadFailureReleasesPin(t *testing.T) {
+	configureCache(t, "1MB", time.Hour)
+	server, _ := testServer(t, true)
+
+	if _, err := Download(server.URL + "/missing"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+
+	evictionLock.Lock()
+	defer evictionLock.Unlock()
+	if len(pins) != 0 {
+		t.Errorf("expected no pinned entries, got %v", pins)
+	}
+}
+
+func TestEvictionSkipsPinnedEntries(t *testing.T) {
+	// every file is 12 bytes, so the cache holds a single file
+	configureCache(t, "20", time.Hour)
+	server, _ := testServer(t, true)
+
+	first, err := Download(server.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make the first file the least recently used one
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(first, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Download(server.URL + "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first); err != nil {
+		t.Fatalf("pinned file has been evicted: %v", err)
+	}
+
+	Release(first)
+	Release(second)
+	third, err := Download(server.URL + "/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Release(third)
+
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("expected the least recently used file to be evicted, got %v", err)
+	}
+	if _, err := os.Stat(first + cacheMetadataSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the metadata of the evicted file to be removed, got %v", err)
+	}
+	if _, err := os.Stat(third); err != nil {
+		t.Errorf("pinned file has been evicted: %v", err)
+	}
+}
+
+func TestReleaseCountsPins(t *testing.T) {
+	configureCache(t, "1MB", time.Hour)
+	server, _ := testServer(t, true)
+
+	first, err := Download(server.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Download(server.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("expected the same cache entry, got %s and %s", first, second)
+	}
+
+	Release(first)
+	evictionLock.Lock()
+	remaining := pins[first]
+	evictionLock.Unlock()
+	if remaining != 1 {
+		t.Errorf("expected a single remaining pin, got %d", remaining)
+	}
+
+	Release(second)
+	evictionLock.Lock()
+	_, pinned := pins[first]
+	evictionLock.Unlock()
+	if pinned {
+		t.Error("expected the entry to be unpinned")
+	}
+
+	entryLocksLock.Lock()
+	defer entryLocksLock.Unlock()
+	if len(entryLocks) != 0 {
+		t.Errorf("expected no remaining entry locks, got %d", len(entryLocks))
+	}
+}
@@ -0,0 +1,224 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	asciiGridKey_Columns        = "ncols"
+	asciiGridKey_Rows           = "nrows"
+	asciiGridKey_XLowerLeft     = "xllcorner"
+	asciiGridKey_YLowerLeft     = "yllcorner"
+	asciiGridKey_XCenter        = "xllcenter"
+	asciiGridKey_YCenter        = "yllcenter"
+	asciiGridKey_CellSize       = "cellsize"
+	asciiGridKey_NoDataValue    = "nodata_value"
+	asciiGridDefaultNoDataValue = -9999
+)
+
+var errMalformedAsciiGrid = errors.New("malformed ascii grid")
+
+// AsciiGrid contains the header of an ESRI ASCII grid in Gauss-Krüger
+// coordinates.
+type AsciiGrid struct {
+	Columns, Rows int
+
+	// XLowerLeft and YLowerLeft contain the coordinates of the lower left
+	// corner of the grid.
+	XLowerLeft, YLowerLeft float64
+
+	CellSize    float64
+	NoDataValue float64
+}
+
+// Project converts a WGS84 coordinate into the fractional column and row of
+// the grid.
+// The rows of the ASCII grids start at the top (northern) edge of the grid.
+func (g AsciiGrid) Project(longitude, latitude float64) (column, row float64) {
+	easting, northing := GaussKruegerZone3(longitude, latitude)
+	top := g.YLowerLeft + float64(g.Rows)*g.CellSize
+	return (easting - g.XLowerLeft) / g.CellSize, (top - northing) / g.CellSize
+}
+
+// GridValue describes how the values of a gridded product are converted into
+// the values of the datapoints.
+type GridValue struct {
+	// Metadata contains the label, description and unit of the datapoints.
+	Metadata v2.FieldMetadata
+
+	// Factor is multiplied with the stored values to get the values in the
+	// unit of the metadata (e.g. 0.1 for temperatures stored in 1/10 °C).
+	Factor float64
+}
+
+// OpenAsciiGrid reads the mean of the area from the ESRI ASCII grid at the
+// path.
+// The grids contain a single field, so the returned archive contains a single
+// datapoint stamped with the start of the interval covered by the grid.
+func OpenAsciiGrid(path string, area GridArea, value GridValue, interval v2.DateTimeRange) (*Archive, error) {
+	mean, valid, err := ReadAsciiGridMean(path, area)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := value.Metadata
+	metadata.ValidFrom, metadata.ValidUntil = interval.Start, interval.End
+
+	dp := v2.Datapoint{
+		Label:     metadata.Name,
+		Timestamp: interval.Start,
+		Interval:  &interval,
+	}
+	if metadata.Unit != "" {
+		dp.Unit = &metadata.Unit
+	}
+	if valid {
+		dp.Value = scaleValue(mean, value.Factor)
+	}
+
+	return &Archive{
+		Metadata:           []v2.FieldMetadata{metadata},
+		Labels:             []string{metadata.Name},
+		bufferedDatapoints: []v2.Datapoint{dp},
+	}, nil
+}
+
+// ReadAsciiGridMean reads the gzip compressed ESRI ASCII grid at the path and
+// calculates the mean of the grid cells covered by the area.
+// Cells without data are excluded from the mean, so that valid is only false
+// if none of the cells contains data.
+func ReadAsciiGridMean(path string, area GridArea) (mean float64, valid bool, err error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var r io.Reader = reader
+	if magic, _ := reader.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) { //nolint:mnd
+		decompressed, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, false, err
+		}
+		defer decompressed.Close()
+		r = decompressed
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	grid, err := readAsciiGridHeader(scanner)
+	if err != nil {
+		return 0, false, err
+	}
+
+	cells := area.Cells(grid.Columns, grid.Rows, grid.Project)
+	if len(cells) == 0 {
+		return 0, false, ErrAreaOutsideGrid
+	}
+	slices.Sort(cells)
+
+	var sum float64
+	var validCells int
+	idx := 0
+	for _, cell := range cells {
+		// skip the values in front of the next selected cell
+		for ; idx < cell; idx++ {
+			if !scanner.Scan() {
+				return 0, false, errMalformedAsciiGrid
+			}
+		}
+		if !scanner.Scan() {
+			return 0, false, errMalformedAsciiGrid
+		}
+		idx++
+
+		value, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("%w: %w", errMalformedAsciiGrid, err)
+		}
+		if value == grid.NoDataValue || math.IsNaN(value) {
+			continue
+		}
+		sum += value
+		validCells++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, false, err
+	}
+
+	if validCells == 0 {
+		return 0, false, nil
+	}
+	return sum / float64(validCells), true, nil
+}
+
+// readAsciiGridHeader reads the key-value pairs in front of the values of the
+// grid.
+// The grids of the DWD always contain all six keys, including the optional
+// value used for cells without data.
+func readAsciiGridHeader(scanner *bufio.Scanner) (grid AsciiGrid, err error) {
+	grid.NoDataValue = asciiGridDefaultNoDataValue
+	centered := false
+
+	for range 6 { //nolint:mnd
+		if !scanner.Scan() {
+			return grid, errMalformedAsciiGrid
+		}
+		key := strings.ToLower(scanner.Text())
+		if !scanner.Scan() {
+			return grid, errMalformedAsciiGrid
+		}
+		value := scanner.Text()
+
+		switch key {
+		case asciiGridKey_Columns:
+			grid.Columns, err = strconv.Atoi(value)
+		case asciiGridKey_Rows:
+			grid.Rows, err = strconv.Atoi(value)
+		case asciiGridKey_XLowerLeft:
+			grid.XLowerLeft, err = strconv.ParseFloat(value, 64)
+		case asciiGridKey_YLowerLeft:
+			grid.YLowerLeft, err = strconv.ParseFloat(value, 64)
+		case asciiGridKey_XCenter:
+			centered = true
+			grid.XLowerLeft, err = strconv.ParseFloat(value, 64)
+		case asciiGridKey_YCenter:
+			centered = true
+			grid.YLowerLeft, err = strconv.ParseFloat(value, 64)
+		case asciiGridKey_CellSize:
+			grid.CellSize, err = strconv.ParseFloat(value, 64)
+		case asciiGridKey_NoDataValue:
+			grid.NoDataValue, err = strconv.ParseFloat(value, 64)
+		default:
+			return grid, fmt.Errorf("%w: unknown header %q", errMalformedAsciiGrid, key)
+		}
+		if err != nil {
+			return grid, fmt.Errorf("%w: %w", errMalformedAsciiGrid, err)
+		}
+	}
+
+	if grid.Columns <= 0 || grid.Rows <= 0 || grid.CellSize <= 0 {
+		return grid, errMalformedAsciiGrid
+	}
+
+	if centered {
+		grid.XLowerLeft -= grid.CellSize / 2 //nolint:mnd
+		grid.YLowerLeft -= grid.CellSize / 2 //nolint:mnd
+	}
+
+	return grid, nil
+}
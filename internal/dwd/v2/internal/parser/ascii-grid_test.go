@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+func TestReadAsciiGridHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected AsciiGrid
+		err      bool
+	}{
+		{
+			name:   "lower left corner",
+			header: "NCOLS 654\nNROWS 866\nXLLCORNER 3280415\nYLLCORNER 5237501\nCELLSIZE 1000\nNODATA_VALUE -999\n",
+			expected: AsciiGrid{
+				Columns: 654, Rows: 866, XLowerLeft: 3280415, YLowerLeft: 5237501, CellSize: 1000, NoDataValue: -999,
+			},
+		},
+		{
+			// the center of the lower left cell is moved to its corner
+			name:   "lower left center",
+			header: "ncols 3\nnrows 2\nxllcenter 3500500\nyllcenter 5650500\ncellsize 1000\nnodata_value -9999\n",
+			expected: AsciiGrid{
+				Columns: 3, Rows: 2, XLowerLeft: 3500000, YLowerLeft: 5650000, CellSize: 1000, NoDataValue: -9999,
+			},
+		},
+		{
+			name:   "keys in another order",
+			header: "cellsize 1000\nxllcorner 1\nyllcorner 2\nnodata_value -1\nnrows 2\nncols 3\n",
+			expected: AsciiGrid{
+				Columns: 3, Rows: 2, XLowerLeft: 1, YLowerLeft: 2, CellSize: 1000, NoDataValue: -1,
+			},
+		},
+		{name: "unknown key", header: "ncols 3\nnrows 2\nxllcorner 1\nyllcorner 2\ncellsize 1000\nfoo 1\n", err: true},
+		{name: "missing key", header: "ncols 3\nnrows 2\nxllcorner 1\nyllcorner 2\ncellsize 1000\n", err: true},
+		{name: "malformed value", header: "ncols x\nnrows 2\nxllcorner 1\nyllcorner 2\ncellsize 1000\nnodata_value -1\n", err: true}, //nolint:lll
+		{name: "empty grid", header: "ncols 0\nnrows 2\nxllcorner 1\nyllcorner 2\ncellsize 1000\nnodata_value -1\n", err: true},      //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(strings.NewReader(tt.header))
+			scanner.Split(bufio.ScanWords)
+
+			grid, err := readAsciiGridHeader(scanner)
+			if tt.err {
+				if !errors.Is(err, errMalformedAsciiGrid) {
+					t.Errorf("expected a malformed grid, got %+v (%v)", grid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if grid != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, grid)
+			}
+		})
+	}
+}
+
+// writeAsciiGrid writes the gzip compressed grid to a temporary directory and
+// returns its path.
+func writeAsciiGrid(t *testing.T, grid string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "grids_germany_monthly_air_temp_mean_202301.asc.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := gzip.NewWriter(f)
+	if _, err := writer.Write([]byte(grid)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sampleAsciiGrid is a grid of 3 × 3 cells around the Gauss-Krüger
+// coordinates of 9°E 51°N (3500073.575/5651645.883), which are located in the
+// center cell.
+const sampleAsciiGrid = `ncols 3
+nrows 3
+xllcorner 3499000
+yllcorner 5650000
+cellsize 1000
+nodata_value -999
+11 12 13
+14 15 16
+17 -999 19
+`
+
+func TestReadAsciiGridMean(t *testing.T) {
+	path := writeAsciiGrid(t, sampleAsciiGrid)
+
+	point, err := NewGridArea(geom.NewPointFlat(geom.XY, []float64{9, 51}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mean, valid, err := ReadAsciiGridMean(path, point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !valid || mean != 15 {
+		t.Errorf("expected the value of the center cell, got %v (valid: %t)", mean, valid)
+	}
+
+	// the polygon covers the centers of the cells of the lower two rows, the
+	// cell without data is excluded from the mean
+	polygon, err := geom.NewPolygon(geom.XY).SetCoords([][]geom.Coord{{
+		{8.97, 50.98}, {9.03, 50.98}, {9.03, 50.9999}, {8.97, 50.9999}, {8.97, 50.98},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	area, err := NewGridArea(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mean, valid, err = ReadAsciiGridMean(path, area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (14.0 + 15 + 16 + 17 + 19) / 5; !valid || mean != expected {
+		t.Errorf("expected %v, got %v (valid: %t)", expected, mean, valid)
+	}
+
+	outside, err := NewGridArea(geom.NewPointFlat(geom.XY, []float64{10, 51}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadAsciiGridMean(path, outside); !errors.Is(err, ErrAreaOutsideGrid) {
+		t.Errorf("expected ErrAreaOutsideGrid, got %v", err)
+	}
+}
+
+func TestOpenAsciiGrid(t *testing.T) {
+	point, err := NewGridArea(geom.NewPointFlat(geom.XY, []float64{9, 51}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := v2.DateTimeRange{
+		Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	value := GridValue{Metadata: v2.FieldMetadata{Name: "air_temperature_mean", Unit: "°C"}, Factor: 0.1}
+
+	archive, err := OpenAsciiGrid(writeAsciiGrid(t, sampleAsciiGrid), point, value, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var datapoints []v2.Datapoint
+	for dp, err := range archive.Datapoints() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		datapoints = append(datapoints, dp)
+	}
+	if len(datapoints) != 1 {
+		t.Fatalf("expected a single datapoint, got %v", datapoints)
+	}
+	dp := datapoints[0]
+	if dp.Label != "air_temperature_mean" || !dp.Timestamp.Equal(interval.Start) || dp.Value != 1.5 {
+		t.Errorf("unexpected datapoint %+v", dp)
+	}
+	if dp.Unit == nil || *dp.Unit != "°C" {
+		t.Errorf("expected the unit °C, got %v", dp.Unit)
+	}
+	if len(archive.Metadata) != 1 || !archive.Metadata[0].ValidUntil.Equal(interval.End) {
+		t.Errorf("unexpected metadata %+v", archive.Metadata)
+	}
+}
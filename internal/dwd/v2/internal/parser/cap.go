@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+// capPreferredLanguage is the language of the alert information preferred if
+// an alert contains the information in several languages.
+const capPreferredLanguage = "de-DE"
+
+var errMalformedCapPolygon = errors.New("malformed CAP polygon")
+
+// capAlert is a CAP 1.2 alert as published by the DWD.
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Sent       string    `xml:"sent"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	References string    `xml:"references"`
+	Info       []capInfo `xml:"info"`
+}
+
+// capInfo contains the description of an alert in a language.
+type capInfo struct {
+	Language    string    `xml:"language"`
+	Event       string    `xml:"event"`
+	Severity    string    `xml:"severity"`
+	Urgency     string    `xml:"urgency"`
+	Certainty   string    `xml:"certainty"`
+	Effective   string    `xml:"effective"`
+	Onset       string    `xml:"onset"`
+	Expires     string    `xml:"expires"`
+	Headline    string    `xml:"headline"`
+	Description string    `xml:"description"`
+	Instruction string    `xml:"instruction"`
+	Areas       []capArea `xml:"area"`
+}
+
+// capArea is an area affected by an alert.
+type capArea struct {
+	Description string   `xml:"areaDesc"`
+	Polygons    []string `xml:"polygon"`
+	Geocodes    []struct {
+		Name  string `xml:"valueName"`
+		Value string `xml:"value"`
+	} `xml:"geocode"`
+}
+
+// ReadCapAlerts reads the CAP alerts contained in the zip archive at the path.
+// The archives published by the DWD contain a XML document per alert.
+// If an alert contains its information in several languages, the German
+// information is used.
+func ReadCapAlerts(path string) ([]v2.Warning, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	warnings := make([]v2.Warning, 0, len(archive.File))
+	for _, file := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".xml") {
+			continue
+		}
+
+		warning, err := readCapAlert(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		warnings = append(warnings, warning)
+	}
+
+	return warnings, nil
+}
+
+// readCapAlert reads a single CAP alert from the archive.
+func readCapAlert(file *zip.File) (v2.Warning, error) {
+	r, err := file.Open()
+	if err != nil {
+		return v2.Warning{}, err
+	}
+	defer r.Close()
+
+	var alert capAlert
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = xmlCharsetReader
+	if err := decoder.Decode(&alert); err != nil {
+		return v2.Warning{}, err
+	}
+
+	return alert.warning()
+}
+
+// warning converts the alert into a warning.
+func (a capAlert) warning() (v2.Warning, error) {
+	warning := v2.Warning{
+		ID:          strings.TrimSpace(a.Identifier),
+		Status:      strings.TrimSpace(a.Status),
+		MessageType: strings.TrimSpace(a.MsgType),
+		References:  parseCapReferences(a.References),
+	}
+
+	var err error
+	if warning.Sent, err = parseCapTime(a.Sent); err != nil {
+		return v2.Warning{}, err
+	}
+	if len(a.Info) == 0 {
+		return warning, nil
+	}
+
+	info := a.Info[0]
+	for _, candidate := range a.Info {
+		if strings.EqualFold(strings.TrimSpace(candidate.Language), capPreferredLanguage) {
+			info = candidate
+			break
+		}
+	}
+
+	warning.Event = strings.TrimSpace(info.Event)
+	warning.Urgency = strings.TrimSpace(info.Urgency)
+	warning.Certainty = strings.TrimSpace(info.Certainty)
+	warning.Headline = strings.TrimSpace(info.Headline)
+	warning.Description = strings.TrimSpace(info.Description)
+	warning.Instruction = strings.TrimSpace(info.Instruction)
+	// unknown severities are kept as such instead of rejecting the alert
+	_ = warning.Severity.Parse(info.Severity)
+
+	// the onset defaults to the effective time, which itself defaults to the
+	// time the alert has been sent
+	warning.Onset = warning.Sent
+	for _, onset := range []string{info.Effective, info.Onset} {
+		if strings.TrimSpace(onset) == "" {
+			continue
+		}
+		if warning.Onset, err = parseCapTime(onset); err != nil {
+			return v2.Warning{}, err
+		}
+	}
+	if strings.TrimSpace(info.Expires) != "" {
+		if warning.Expires, err = parseCapTime(info.Expires); err != nil {
+			return v2.Warning{}, err
+		}
+	}
+
+	for _, area := range info.Areas {
+		warningArea := v2.WarningArea{
+			Description: strings.TrimSpace(area.Description),
+			Geocodes:    make(map[string][]string),
+		}
+		for _, geocode := range area.Geocodes {
+			name := strings.TrimSpace(geocode.Name)
+			warningArea.Geocodes[name] = append(warningArea.Geocodes[name], strings.TrimSpace(geocode.Value))
+		}
+		for _, polygon := range area.Polygons {
+			parsed, err := parseCapPolygon(polygon)
+			if err != nil {
+				return v2.Warning{}, err
+			}
+			warningArea.Polygons = append(warningArea.Polygons, parsed)
+		}
+		warning.Areas = append(warning.Areas, warningArea)
+	}
+
+	return warning, nil
+}
+
+// parseCapTime parses a timestamp of an alert and converts it into UTC.
+func parseCapTime(s string) (time.Time, error) {
+	ts, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.UTC(), nil
+}
+
+// parseCapReferences returns the identifiers of the referenced alerts, which
+// are listed as space separated `<sender>,<identifier>,<sent>` triples.
+func parseCapReferences(references string) []string {
+	var identifiers []string
+	for _, reference := range strings.Fields(references) {
+		parts := strings.Split(reference, ",")
+		if len(parts) < 2 { //nolint:mnd
+			continue
+		}
+		identifiers = append(identifiers, parts[1])
+	}
+	return identifiers
+}
+
+// parseCapPolygon parses a polygon of an alert, which is given as space
+// separated `<latitude>,<longitude>` pairs.
+// The polygon is returned with the coordinates given as longitude and
+// latitude.
+func parseCapPolygon(s string) (*geom.Polygon, error) {
+	pairs := strings.Fields(s)
+	if len(pairs) < 3 { //nolint:mnd
+		return nil, errMalformedCapPolygon
+	}
+
+	coordinates := make([]float64, 0, 2*len(pairs)) //nolint:mnd
+	for _, pair := range pairs {
+		parts := strings.Split(pair, ",")
+		if len(parts) != 2 { //nolint:mnd
+			return nil, errMalformedCapPolygon
+		}
+		latitude, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		longitude, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		coordinates = append(coordinates, longitude, latitude)
+	}
+
+	polygon := geom.NewPolygonFlat(geom.XY, coordinates, []int{len(coordinates)})
+	polygon.SetSRID(coordinateSRID)
+	return polygon, nil
+}
@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+// sampleCapAlert is a CAP alert trimmed down from a DWD snapshot, which
+// contains the English information in front of the German information.
+//
+//nolint:lll
+const sampleCapAlert = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+    <identifier>2.49.0.0.276.0.DWD.PVW.1704875400000.a1</identifier>
+    <sender>opendata@dwd.de</sender>
+    <sent>2024-01-10T09:30:00+01:00</sent>
+    <status>Actual</status>
+    <msgType>Update</msgType>
+    <scope>Public</scope>
+    <references>opendata@dwd.de,2.49.0.0.276.0.DWD.PVW.1704870000000.a0,2024-01-10T08:00:00+01:00 opendata@dwd.de,2.49.0.0.276.0.DWD.PVW.1704866400000.z9,2024-01-10T07:00:00+01:00</references>
+    <info>
+        <language>en-GB</language>
+        <event>FROST</event>
+        <severity>Moderate</severity>
+        <headline>Official WARNING of FROST</headline>
+    </info>
+    <info>
+        <language>de-DE</language>
+        <event>FROST</event>
+        <urgency>Immediate</urgency>
+        <severity>Moderate</severity>
+        <certainty>Likely</certainty>
+        <effective>2024-01-10T09:30:00+01:00</effective>
+        <onset>2024-01-10T18:00:00+01:00</onset>
+        <expires>2024-01-11T10:00:00+01:00</expires>
+        <headline>Amtliche WARNUNG vor FROST</headline>
+        <description>Es tritt Frost zwischen -5 °C und -10 °C auf.</description>
+        <area>
+            <areaDesc>Stadt Oldenburg</areaDesc>
+            <polygon>53.1,8.1 53.1,8.3 53.2,8.3 53.2,8.1 53.1,8.1</polygon>
+            <geocode>
+                <valueName>WARNCELLID</valueName>
+                <value>803403000</value>
+            </geocode>
+        </area>
+    </info>
+</alert>
+`
+
+// writeCapArchive writes a zip archive containing the alerts to a temporary
+// directory and returns its path.
+func writeCapArchive(t *testing.T, alerts map[string][]byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "Z_CAP_C_EDZW_LATEST_PVW_STATUS_PREMIUMDWD_COMMUNEUNION_DE.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	for name, content := range alerts {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCapAlerts(t *testing.T) {
+	latin1, err := charmap.ISO8859_1.NewEncoder().String(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+    <identifier>2.49.0.0.276.0.DWD.PVW.1704880000000.b1</identifier>
+    <sent>2024-01-10T10:00:00+01:00</sent>
+    <status>Actual</status>
+    <msgType>Alert</msgType>
+    <info>
+        <language>de-DE</language>
+        <event>GLÄTTE</event>
+        <severity>Minor</severity>
+        <area>
+            <areaDesc>Gemeinde Südbrookmerland</areaDesc>
+        </area>
+    </info>
+</alert>
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel := `<?xml version="1.0" encoding="UTF-8"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+    <identifier>2.49.0.0.276.0.DWD.PVW.1704890000000.c1</identifier>
+    <sent>2024-01-10T12:30:00Z</sent>
+    <status>Actual</status>
+    <msgType>Cancel</msgType>
+    <references>opendata@dwd.de,2.49.0.0.276.0.DWD.PVW.1704880000000.b1,2024-01-10T10:00:00+01:00</references>
+</alert>
+`
+
+	path := writeCapArchive(t, map[string][]byte{
+		"update.xml":  []byte(sampleCapAlert),
+		"latin1.xml":  []byte(latin1),
+		"cancel.xml":  []byte(cancel),
+		"README.html": []byte("<html></html>"),
+	})
+	warnings, err := ReadCapAlerts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 3 {
+		t.Fatalf("expected 3 warnings, got %d", len(warnings))
+	}
+	slices.SortFunc(warnings, func(a, b v2.Warning) int {
+		return a.Sent.Compare(b.Sent)
+	})
+
+	tests := []struct {
+		name  string
+		check func(t *testing.T, warning v2.Warning)
+	}{
+		{
+			name: "update",
+			check: func(t *testing.T, warning v2.Warning) {
+				if warning.MessageType != "Update" || warning.Status != "Actual" {
+					t.Errorf("unexpected message type %s (%s)", warning.MessageType, warning.Status)
+				}
+				expectedReferences := []string{
+					"2.49.0.0.276.0.DWD.PVW.1704870000000.a0",
+					"2.49.0.0.276.0.DWD.PVW.1704866400000.z9",
+				}
+				if !slices.Equal(warning.References, expectedReferences) {
+					t.Errorf("expected references %v, got %v", expectedReferences, warning.References)
+				}
+				// the German information is preferred
+				if warning.Headline != "Amtliche WARNUNG vor FROST" || warning.Urgency != "Immediate" {
+					t.Errorf("unexpected information %q (%s)", warning.Headline, warning.Urgency)
+				}
+				if warning.Severity != dwdTypes.Severity_Moderate {
+					t.Errorf("unexpected severity %v", warning.Severity)
+				}
+				// the onset takes precedence over the effective time
+				if expected := time.Date(2024, 1, 10, 17, 0, 0, 0, time.UTC); !warning.Onset.Equal(expected) {
+					t.Errorf("expected onset %s, got %s", expected, warning.Onset)
+				}
+				if expected := time.Date(2024, 1, 11, 9, 0, 0, 0, time.UTC); !warning.Expires.Equal(expected) {
+					t.Errorf("expected expiry %s, got %s", expected, warning.Expires)
+				}
+
+				if len(warning.Areas) != 1 || len(warning.Areas[0].Polygons) != 1 {
+					t.Fatalf("unexpected areas %+v", warning.Areas)
+				}
+				area := warning.Areas[0]
+				if area.Description != "Stadt Oldenburg" || !slices.Equal(area.Geocodes["WARNCELLID"], []string{"803403000"}) {
+					t.Errorf("unexpected area %s with geocodes %v", area.Description, area.Geocodes)
+				}
+				// the polygons are converted into longitude and latitude
+				if first := area.Polygons[0].Coord(0); first.X() != 8.1 || first.Y() != 53.1 {
+					t.Errorf("unexpected first coordinate %v", first)
+				}
+			},
+		},
+		{
+			name: "ISO-8859-1 alert",
+			check: func(t *testing.T, warning v2.Warning) {
+				if warning.Event != "GLÄTTE" || len(warning.Areas) != 1 ||
+					warning.Areas[0].Description != "Gemeinde Südbrookmerland" {
+					t.Errorf("unexpected decoding of %q and %+v", warning.Event, warning.Areas)
+				}
+				// the onset defaults to the time the alert has been sent
+				if !warning.Onset.Equal(warning.Sent) || !warning.Expires.IsZero() {
+					t.Errorf("unexpected onset %s and expiry %s", warning.Onset, warning.Expires)
+				}
+			},
+		},
+		{
+			name: "cancel",
+			check: func(t *testing.T, warning v2.Warning) {
+				if warning.MessageType != "Cancel" ||
+					!slices.Equal(warning.References, []string{"2.49.0.0.276.0.DWD.PVW.1704880000000.b1"}) {
+					t.Errorf("unexpected cancellation %s of %v", warning.MessageType, warning.References)
+				}
+				if len(warning.Areas) != 0 || !warning.Onset.IsZero() {
+					t.Errorf("expected a cancellation without information, got %+v", warning)
+				}
+			},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, warnings[idx])
+		})
+	}
+}
+
+func TestReadCapAlertsRejectsMalformedAlerts(t *testing.T) {
+	tests := map[string]string{
+		"malformed sent time": `<alert><identifier>a</identifier><sent>yesterday</sent></alert>`,
+		"malformed onset":     `<alert><identifier>a</identifier><sent>2024-01-10T10:00:00Z</sent><info><onset>soon</onset></info></alert>`, //nolint:lll
+		"unsupported charset": `<?xml version="1.0" encoding="KOI8-R"?><alert><identifier>a</identifier></alert>`,
+		"malformed polygon":   `<alert><sent>2024-01-10T10:00:00Z</sent><info><area><polygon>53.1,8.1 53.1</polygon></area></info></alert>`, //nolint:lll
+	}
+
+	for name, alert := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeCapArchive(t, map[string][]byte{"alert.xml": []byte(alert)})
+			if warnings, err := ReadCapAlerts(path); err == nil {
+				t.Errorf("expected an error, got %+v", warnings)
+			}
+		})
+	}
+}
+
+func TestParseCapPolygon(t *testing.T) {
+	tests := []struct {
+		name        string
+		polygon     string
+		coordinates int
+		err         bool
+	}{
+		{name: "closed ring", polygon: "53.1,8.1 53.1,8.3 53.2,8.3 53.1,8.1", coordinates: 4},
+		{name: "surrounding whitespace", polygon: "\n 53.1,8.1  53.1,8.3\t53.2,8.3 \n", coordinates: 3},
+		{name: "too few pairs", polygon: "53.1,8.1 53.1,8.3", err: true},
+		{name: "missing longitude", polygon: "53.1,8.1 53.1 53.2,8.3", err: true},
+		{name: "malformed number", polygon: "53.1,8.1 53.1,x 53.2,8.3", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygon, err := parseCapPolygon(tt.polygon)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", polygon.FlatCoords())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if polygon.NumCoords() != tt.coordinates || polygon.SRID() != coordinateSRID {
+				t.Errorf("expected %d coordinates, got %v (SRID %d)", tt.coordinates, polygon.FlatCoords(), polygon.SRID())
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+// df_ClimatFileName is the format of the months in the names of the
+// historical CLIMAT files.
+const df_ClimatFileName = "200601"
+
+// climatMissingValue is the lower bound of the values used to mark missing
+// values in the CLIMAT files (e.g. -999 or -9999).
+const climatMissingValue = -999
+
+// The CLIMAT files are published with German and English headers, so the
+// columns are looked up using all known names.
+var (
+	climatColumns_StationID = []string{"WMO-Station ID", "WMO-Stationskennung", "WMO_ID", "Station", "Stations_id", "ID"}
+	climatColumns_Name      = []string{"StationName", "Station Name", "Stationsname", "Name"}
+	climatColumns_Latitude  = []string{"Latitude", "geogr. Breite", "geograph.Breite", "Breite"}
+	climatColumns_Longitude = []string{"Longitude", "geogr. Laenge", "geograph.Laenge", "Laenge"}
+	climatColumns_Height    = []string{"Height", "Stationshoehe", "Hoehe"}
+	climatColumns_Year      = []string{"Jahr", "Year"}
+)
+
+// climatFileNamePattern matches the names of the CLIMAT files, which start
+// with the WMO id of the station followed by the covered months for the
+// historical files, e.g. 10488_195101_202312.txt.
+var climatFileNamePattern = regexp.MustCompile(`^(\d{5})(?:_(\d{6})_(\d{6}))?\.txt$`)
+
+var errUnsupportedClimatFile = errors.New("unsupported CLIMAT file")
+
+// ParseClimatFileName returns the WMO id of the station contained in the
+// CLIMAT file with the name and the months covered by the file.
+// The recent files carry no months in their names and return an empty range.
+func ParseClimatFileName(name string) (stationID string, covered v2.DateTimeRange, ok bool) {
+	match := climatFileNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", covered, false
+	}
+	if match[2] == "" {
+		return match[1], covered, true
+	}
+
+	start, err := time.Parse(df_ClimatFileName, match[2])
+	if err != nil {
+		return "", covered, false
+	}
+	end, err := time.Parse(df_ClimatFileName, match[3])
+	if err != nil {
+		return "", covered, false
+	}
+	return match[1], v2.DateTimeRange{Start: start, End: end.AddDate(0, 1, -1)}, true
+}
+
+// parseClimatNumber parses a number which may use a decimal comma.
+func parseClimatNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+// ReadClimatStations reads the stations contained in the CLIMAT station list.
+// The stations are identified by their five-digit WMO ids.
+func ReadClimatStations(path string) ([]v2.Station, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table, err := openSemicolonTable(f)
+	if err != nil {
+		return nil, err
+	}
+
+	idColumn, foundID := table.findColumn(climatColumns_StationID...)
+	latitudeColumn, foundLatitude := table.findColumn(climatColumns_Latitude...)
+	longitudeColumn, foundLongitude := table.findColumn(climatColumns_Longitude...)
+	if !foundID || !foundLatitude || !foundLongitude {
+		return nil, errUnsupportedClimatFile
+	}
+	nameColumn, _ := table.findColumn(climatColumns_Name...)
+	heightColumn, _ := table.findColumn(climatColumns_Height...)
+
+	var stations []v2.Station
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		id := row(idColumn)
+		if id == "" {
+			continue
+		}
+		// the WMO ids are zero-padded to match the names of the datafiles
+		if numericID, err := strconv.Atoi(id); err == nil {
+			id = padStationID(numericID)
+		}
+
+		latitude, err := parseClimatNumber(row(latitudeColumn))
+		if err != nil {
+			continue
+		}
+		longitude, err := parseClimatNumber(row(longitudeColumn))
+		if err != nil {
+			continue
+		}
+		height, _ := parseClimatNumber(row(heightColumn))
+
+		location := geom.NewPointFlat(geom.XYZ, []float64{longitude, latitude, height})
+		location.SetSRID(coordinateSRID)
+
+		stations = append(stations, v2.Station{
+			ID:       id,
+			Name:     row(nameColumn),
+			Height:   height,
+			Location: location,
+		})
+	}
+
+	return stations, nil
+}
+
+// OpenClimatObservations reads the monthly values of the station from the
+// CLIMAT file at the path.
+// The files contain a row per year with the values of the twelve months in
+// the columns following the year.
+// The datapoints are stamped with the start of the month and carry the month
+// as their interval, while missing values are returned as datapoints without
+// a value.
+// If labels are supplied, only the parameters with these labels are read.
+func OpenClimatObservations(path, stationID string, metadata v2.FieldMetadata, labels []string) (*Archive, error) {
+	archive := &Archive{selectedLabels: labels}
+	if !archive.selected(metadata.Name) {
+		return archive, nil
+	}
+
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table, err := openSemicolonTable(f)
+	if err != nil {
+		return nil, err
+	}
+
+	yearColumn, found := table.findColumn(climatColumns_Year...)
+	if !found || table.columns[yearColumn]+12 >= len(table.header) { //nolint:mnd
+		return nil, errUnsupportedClimatFile
+	}
+	monthColumns := table.header[table.columns[yearColumn]+1 : table.columns[yearColumn]+13] //nolint:mnd
+	idColumn, _ := table.findColumn(climatColumns_StationID...)
+
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		if idColumn != "" && !sameStation(row(idColumn), stationID) {
+			continue
+		}
+
+		year, err := strconv.Atoi(row(yearColumn))
+		if err != nil {
+			continue
+		}
+
+		for month, column := range monthColumns {
+			start := time.Date(year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC)
+			interval := v2.DateTimeRange{Start: start, End: start.AddDate(0, 1, -1)}
+
+			if metadata.ValidFrom.IsZero() || start.Before(metadata.ValidFrom) {
+				metadata.ValidFrom = start
+			}
+			if interval.End.After(metadata.ValidUntil) {
+				metadata.ValidUntil = interval.End
+			}
+
+			dp := v2.Datapoint{
+				Label:     metadata.Name,
+				Timestamp: start,
+				Interval:  &interval,
+			}
+			if metadata.Unit != "" {
+				dp.Unit = &metadata.Unit
+			}
+			if value, err := parseClimatNumber(row(column)); err == nil && value > climatMissingValue {
+				dp.Value = value
+			}
+			archive.bufferedDatapoints = append(archive.bufferedDatapoints, dp)
+		}
+	}
+
+	archive.Metadata = []v2.FieldMetadata{metadata}
+	archive.Labels = []string{metadata.Name}
+	return archive, nil
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+
+	v2 "microservice/types/v2"
+)
+
+// writeClimatFile writes the content encoded in Windows-1252 to a temporary
+// file and returns its path.
+func writeClimatFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	encoded, err := charmap.Windows1252.NewEncoder().String(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(encoded), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseClimatFileName(t *testing.T) {
+	tests := []struct {
+		name      string
+		stationID string
+		covered   v2.DateTimeRange
+		ok        bool
+	}{
+		{
+			name:      "10488_195101_202312.txt",
+			stationID: "10488",
+			covered: v2.DateTimeRange{
+				Start: time.Date(1951, time.January, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC),
+			},
+			ok: true,
+		},
+		{
+			name:      "01001_200402_200402.txt",
+			stationID: "01001",
+			covered: v2.DateTimeRange{
+				Start: time.Date(2004, time.February, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2004, time.February, 29, 0, 0, 0, 0, time.UTC),
+			},
+			ok: true,
+		},
+		{name: "10488.txt", stationID: "10488", ok: true},
+		{name: "10488_195113_202312.txt"},
+		{name: "1048_195101_202312.txt"},
+		{name: "10488_195101.txt"},
+		{name: "CLIMAT_RR_stations.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stationID, covered, ok := ParseClimatFileName(tt.name)
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %t, got %t", tt.ok, ok)
+			}
+			if stationID != tt.stationID || !covered.Start.Equal(tt.covered.Start) || !covered.End.Equal(tt.covered.End) {
+				t.Errorf("expected %s covering %+v, got %s covering %+v", tt.stationID, tt.covered, stationID, covered)
+			}
+		})
+	}
+}
+
+func TestReadClimatStations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "english header",
+			content: "WMO-Station ID;StationName;Latitude;Longitude;Height;Country\n" +
+				"1001;Jan Mayen;70.93;-8.67;10;Norway\n" +
+				"10488;Dresden-Klotzsche;51.13;13.75;222;Germany\n",
+		},
+		{
+			// the German station lists use decimal commas
+			name: "german header",
+			content: "WMO-Stationskennung; Stationsname; geogr. Breite; geogr. Laenge; Stationshoehe\n" +
+				"01001; Jan Mayen; 70,93; -8,67; 10\n" +
+				"10488; Dresden-Klotzsche; 51,13; 13,75; 222\n" +
+				"; Leerzeile; ; ; \n" +
+				"10999; ohne Koordinaten; ; ; \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stations, err := ReadClimatStations(writeClimatFile(t, "CLIMAT_stations.txt", tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stations) != 2 {
+				t.Fatalf("expected 2 stations, got %v", stations)
+			}
+
+			if stations[0].ID != "01001" || stations[0].Name != "Jan Mayen" {
+				t.Errorf("unexpected station %s (%s)", stations[0].ID, stations[0].Name)
+			}
+			dresden := stations[1]
+			if dresden.ID != "10488" || dresden.Name != "Dresden-Klotzsche" || dresden.Height != 222 {
+				t.Errorf("unexpected station %s (%s) at %v m", dresden.ID, dresden.Name, dresden.Height)
+			}
+			if dresden.Location.X() != 13.75 || dresden.Location.Y() != 51.13 || dresden.Location.Z() != 222 {
+				t.Errorf("unexpected location %v", dresden.Location.Coords())
+			}
+		})
+	}
+
+	path := writeClimatFile(t, "CLIMAT_stations.txt", "Station;Name\n10488;Dresden-Klotzsche\n")
+	if _, err := ReadClimatStations(path); !errors.Is(err, errUnsupportedClimatFile) {
+		t.Errorf("expected errUnsupportedClimatFile for a list without coordinates, got %v", err)
+	}
+}
+
+func TestOpenClimatObservations(t *testing.T) {
+	metadata := v2.FieldMetadata{Name: "precipitation", Unit: "mm"}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "german header",
+			content: "Station;Jahr;Jan;Feb;Mrz;Apr;Mai;Jun;Jul;Aug;Sep;Okt;Nov;Dez\n" +
+				"10488;2023;1,5;2;3;4;5;6;7;8;9;10;11;12\n" +
+				"10489;2023;99;99;99;99;99;99;99;99;99;99;99;99\n" +
+				"10488;2024;13;-999;-9999;;x;18;19;20;21;22;23;24\n",
+		},
+		{
+			// the twelve columns following the year contain the months, so
+			// columns behind them are ignored
+			name: "english header with trailing columns",
+			content: "WMO_ID;Year;January;February;March;April;May;June;July;August;September;October;November;December;Annual\n" + //nolint:lll
+				"10488;2023;1.5;2;3;4;5;6;7;8;9;10;11;12;78\n" +
+				"10488;2024;13;-999;-9999;;x;18;19;20;21;22;23;24;1000\n",
+		},
+		{
+			name: "without station column",
+			content: "Year;Jan;Feb;Mar;Apr;May;Jun;Jul;Aug;Sep;Oct;Nov;Dec\n" +
+				"2023;1.5;2;3;4;5;6;7;8;9;10;11;12\n" +
+				"2024;13;-999;-9999;;x;18;19;20;21;22;23;24\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := OpenClimatObservations(writeClimatFile(t, "10488.txt", tt.content), "10488", metadata, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var datapoints []v2.Datapoint
+			for dp, err := range archive.Datapoints() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				datapoints = append(datapoints, dp)
+			}
+			if len(datapoints) != 24 {
+				t.Fatalf("expected 24 monthly datapoints, got %d", len(datapoints))
+			}
+
+			expected := map[int]any{0: 1.5, 11: 12.0, 12: 13.0, 13: nil, 14: nil, 15: nil, 16: nil, 23: 24.0}
+			for idx, value := range expected {
+				dp := datapoints[idx]
+				start := time.Date(2023+idx/12, time.Month(idx%12+1), 1, 0, 0, 0, 0, time.UTC)
+				if !dp.Timestamp.Equal(start) || dp.Value != value {
+					t.Errorf("expected %v at %s, got %v at %s", value, start, dp.Value, dp.Timestamp)
+				}
+				if dp.Interval == nil || !dp.Interval.End.Equal(start.AddDate(0, 1, -1)) {
+					t.Errorf("%s: unexpected interval %+v", start, dp.Interval)
+				}
+			}
+
+			if len(archive.Metadata) != 1 || archive.Metadata[0].Name != metadata.Name ||
+				!archive.Metadata[0].ValidFrom.Equal(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)) ||
+				!archive.Metadata[0].ValidUntil.Equal(time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected metadata %+v", archive.Metadata)
+			}
+		})
+	}
+
+	t.Run("unselected label", func(t *testing.T) {
+		archive, err := OpenClimatObservations("missing.txt", "10488", metadata, []string{"pressure"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive.Labels) != 0 {
+			t.Errorf("expected an empty archive, got %v", archive.Labels)
+		}
+	})
+
+	t.Run("missing months", func(t *testing.T) {
+		path := writeClimatFile(t, "10488.txt", "Station;Jahr;Jan;Feb;Mrz\n10488;2023;1;2;3\n")
+		if _, err := OpenClimatObservations(path, "10488", metadata, nil); !errors.Is(err, errUnsupportedClimatFile) {
+			t.Errorf("expected errUnsupportedClimatFile, got %v", err)
+		}
+	})
+}
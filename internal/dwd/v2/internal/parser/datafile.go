@@ -5,6 +5,8 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"slices"
 	"strconv"
 	"strings"
@@ -34,6 +36,8 @@ const (
 const (
 	dataFieldName_StationID      = "STATIONS_ID"
 	dataFieldName_Date           = "MESS_DATUM"
+	dataFieldName_IntervalStart  = "MESS_DATUM_BEGINN"
+	dataFieldName_IntervalEnd    = "MESS_DATUM_ENDE"
 	dataFieldPrefix_QualityLevel = "QN"
 	dataField_EndOfRow           = "eor"
 )
@@ -48,64 +52,132 @@ const (
 	df_missing_day_time = "02.01.2006-15:04"
 )
 
-func ReadArchive(path string) (datapoints []v2.Datapoint, metadata []v2.FieldMetadata, err error) {
-	archive, err := zip.OpenReader(path)
+// Archive is an opened data archive downloaded from the open data portal.
+// The metadata of the archive is read when opening it while the datapoints
+// are read from the archive while iterating over them.
+type Archive struct {
+	reader         *zip.ReadCloser
+	dataFile       *zip.File
+	selectedLabels []string
+
+	// bufferedDatapoints contains the datapoints which are read completely
+	// while opening the archive (e.g. the datapoints generated for missing
+	// values).
+	bufferedDatapoints []v2.Datapoint
+
+	// Metadata contains the descriptions of the parameters contained in the
+	// archive.
+	Metadata []v2.FieldMetadata
+
+	// Labels contains the labels of the parameters in the data file in the
+	// order of their columns.
+	Labels []string
+
+	// Period contains the period folder the archive has been downloaded from
+	// and is attached to all datapoints read from the archive.
+	Period dwdTypes.Period
+}
+
+// OpenArchive opens the archive at the path and reads the metadata contained
+// in it.
+// If labels are supplied, only the parameters with these labels are read from
+// the archive and the metadata of all other parameters is dropped.
+// The returned archive needs to be closed after reading the datapoints.
+func OpenArchive(path string, labels []string) (*Archive, error) {
+	reader, err := zip.OpenReader(path)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	var parsedDatapoints []v2.Datapoint
-	var generatedDatapoints []v2.Datapoint
+	archive := &Archive{reader: reader, selectedLabels: labels}
 
-	for _, file := range archive.File {
+	for _, file := range reader.File {
 		if !strings.HasSuffix(file.Name, ".txt") {
 			continue
 		}
 
-		if strings.HasPrefix(file.Name, filePrefix_Parameters) {
-			metadata, err = parseMetadataFile(file)
-			if err != nil {
-				return nil, nil, err
-			}
-			continue
+		switch {
+		case strings.HasPrefix(file.Name, filePrefix_Parameters):
+			archive.Metadata, err = parseMetadataFile(file)
+		case strings.HasPrefix(file.Name, filePrefix_MissingValues):
+			archive.bufferedDatapoints, err = generateMissingDatapoints(file)
+		case strings.HasPrefix(file.Name, filePrefix_DataFile):
+			archive.dataFile = file
+			archive.Labels, err = readDataLabels(file)
 		}
 
-		if strings.HasPrefix(file.Name, filePrefix_MissingValues) {
-			generatedDatapoints, err = generateMissingDatapoints(file)
-			if err != nil {
-				return nil, nil, err
-			}
-			datapoints = append(datapoints, generatedDatapoints...)
-			continue
+		if err != nil {
+			_ = reader.Close()
+			return nil, err
 		}
+	}
 
-		if !strings.HasPrefix(file.Name, filePrefix_DataFile) {
-			continue
+	archive.Metadata = slices.DeleteFunc(archive.Metadata, func(m v2.FieldMetadata) bool {
+		return !archive.selected(m.Name)
+	})
+	archive.Labels = slices.DeleteFunc(archive.Labels, func(label string) bool {
+		return !archive.selected(label)
+	})
+	archive.bufferedDatapoints = slices.DeleteFunc(archive.bufferedDatapoints, func(dp v2.Datapoint) bool {
+		return !archive.selected(dp.Label)
+	})
+
+	slices.SortStableFunc(archive.bufferedDatapoints, func(a, b v2.Datapoint) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	return archive, nil
+}
+
+// Datapoints returns an iterator over the datapoints contained in the archive
+// ordered by their timestamps.
+// The datapoints generated for missing values are merged into the datapoints
+// read from the data file.
+func (a *Archive) Datapoints() iter.Seq2[v2.Datapoint, error] {
+	bufferedDatapoints := func(yield func(v2.Datapoint, error) bool) {
+		for _, dp := range a.bufferedDatapoints {
+			if !yield(dp, nil) {
+				return
+			}
 		}
+	}
 
-		parsedDatapoints, err = parseDatapointFile(file)
-		if err != nil {
-			return nil, nil, err
+	datapoints := iter.Seq2[v2.Datapoint, error](bufferedDatapoints)
+	if a.dataFile != nil {
+		units := make(map[string]string)
+		for _, metadataField := range a.Metadata {
+			units[metadataField.Name] = metadataField.Unit
 		}
 
+		datapoints = MergeDatapoints(parseDatapointFile(a.dataFile, units, a.selected), bufferedDatapoints)
 	}
 
-	if metadata == nil || datapoints == nil {
-		return
+	if a.Period == dwdTypes.Period_None {
+		return datapoints
 	}
 
-	units := make(map[string]string)
-	for _, metadataField := range metadata {
-		units[metadataField.Name] = metadataField.Unit
+	return func(yield func(v2.Datapoint, error) bool) {
+		for dp, err := range datapoints {
+			dp.Period = a.Period
+			if !yield(dp, err) {
+				return
+			}
+		}
 	}
+}
 
-	for _, dp := range parsedDatapoints {
-		l := units[dp.Label]
-		dp.Unit = &l
-		datapoints = append(datapoints, dp)
-	}
+// selected checks if the parameter with the label has been selected when
+// opening the archive.
+func (a *Archive) selected(label string) bool {
+	return len(a.selectedLabels) == 0 || slices.Contains(a.selectedLabels, label)
+}
 
-	return
+// Close closes the underlying archive.
+func (a *Archive) Close() error {
+	if a.reader == nil {
+		return nil
+	}
+	return a.reader.Close()
 }
 
 func parseMetadataFile(compressedFile *zip.File) (metadata []v2.FieldMetadata, err error) {
@@ -181,100 +253,224 @@ func parseMetadataFile(compressedFile *zip.File) (metadata []v2.FieldMetadata, e
 
 }
 
-func parseDatapointFile(compressedFile *zip.File) (datapoints []v2.Datapoint, err error) {
+// readDataLabels reads the header of the data file and returns the labels of
+// the data columns.
+func readDataLabels(compressedFile *zip.File) (labels []string, err error) {
 	f, err := compressedFile.Open()
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	reader := csv.NewReader(transform.NewReader(f, charmap.Windows1252.NewDecoder().Transformer))
 	reader.TrimLeadingSpace = true
 	reader.Comma = ';'
 
-	lines, err := reader.ReadAll()
+	header, err := reader.Read()
 	if err != nil {
 		return nil, err
 	}
 
-	header := lines[0]
-	ql_idx := -1
-	datacolidxs := make(map[int]string)
+	for _, idx := range dataColumns(header) {
+		labels = append(labels, header[idx])
+	}
+	return labels, nil
+}
 
+// dataColumns returns the indices of the columns in the header which contain
+// parameter values.
+func dataColumns(header []string) (indices []int) {
 	for idx, rowHead := range header {
-		if rowHead == dataFieldName_Date || rowHead == dataFieldName_StationID || rowHead == dataField_EndOfRow {
+		switch rowHead {
+		case dataFieldName_Date, dataFieldName_IntervalStart, dataFieldName_IntervalEnd,
+			dataFieldName_StationID, dataField_EndOfRow:
 			continue
 		}
 
 		if strings.HasPrefix(rowHead, dataFieldPrefix_QualityLevel) {
-			ql_idx = idx
 			continue
 		}
 
-		datacolidxs[idx] = rowHead
+		indices = append(indices, idx)
 	}
+	return indices
+}
 
-	for idx, line := range lines {
-		if idx == 0 {
+// qualityLevelColumns maps the indices of the data columns to the index of the
+// quality level column covering them.
+// The DWD places a quality level column (e.g. QN_3, QN_4) in front of each
+// group of parameters it applies to, so every data column is covered by the
+// closest quality level column preceding it.
+// Data columns without a preceding quality level column are not contained in
+// the returned map.
+func qualityLevelColumns(header []string) map[int]int {
+	columns := make(map[int]int)
+	dataColumnIndices := dataColumns(header)
+
+	ql_idx := -1
+	for idx, rowHead := range header {
+		if strings.HasPrefix(rowHead, dataFieldPrefix_QualityLevel) {
+			ql_idx = idx
 			continue
 		}
 
-		var date time.Time
-		dateString := line[slices.Index(header, dataFieldName_Date)]
-		switch len(dateString) {
-		case len(df_Full):
-			date, err = time.Parse(df_Full, dateString)
-		case len(df_HourOnly):
-			date, err = time.Parse(df_HourOnly, dateString)
-		case len(df_DayOnly):
-			date, err = time.Parse(df_DayOnly, dateString)
-		default:
-			return nil, errors.New("unsupported datetime format")
+		if ql_idx >= 0 && slices.Contains(dataColumnIndices, idx) {
+			columns[idx] = ql_idx
 		}
+	}
+	return columns
+}
+
+// parseQualityLevel parses the value of a quality level column.
+// The quality levels (QN) describe the quality control procedure the values
+// have passed, which the DWD numbers from 1 to 10.
+// Values which are not a known quality level (e.g. empty cells or -999) are
+// treated as a missing quality level instead of failing the whole file.
+func parseQualityLevel(s string) *dwdTypes.QualityControlProcedure {
+	qlInt, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	var qualityLevel dwdTypes.QualityControlProcedure
+	if err := qualityLevel.Parse(qlInt); err != nil {
+		return nil
+	}
+	return &qualityLevel
+}
+
+var errMissingDateColumn = errors.New("data file contains no date column")
+
+// parseDataDate parses a date used in the data files.
+// Dates before the year 2000 are given in MEZ by the DWD.
+func parseDataDate(dateString string, mez *time.Location) (date time.Time, err error) {
+	switch len(dateString) {
+	case len(df_Full):
+		date, err = time.Parse(df_Full, dateString)
+	case len(df_HourOnly):
+		date, err = time.Parse(df_HourOnly, dateString)
+	case len(df_DayOnly):
+		date, err = time.Parse(df_DayOnly, dateString)
+	default:
+		err = errors.New("unsupported datetime format")
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if date.Year() < 2000 { //nolint:mnd
+		date = date.In(mez)
+	}
+	return date, nil
+}
+
+// parseDatapointFile returns an iterator over the datapoints in the data file.
+// The file is read line by line while iterating, and the units are attached to
+// the datapoints by their label.
+// Only the columns of the labels accepted by selected are converted into
+// datapoints.
+func parseDatapointFile(compressedFile *zip.File, units map[string]string, selected func(label string) bool) iter.Seq2[v2.Datapoint, error] { //nolint:lll
+	return func(yield func(v2.Datapoint, error) bool) {
+		f, err := compressedFile.Open()
 		if err != nil {
-			return nil, err
+			yield(v2.Datapoint{}, err)
+			return
 		}
+		defer f.Close()
 
-		if date.Year() < 2000 { //nolint:mnd
-			mez, err := time.LoadLocation("Etc/GMT-1")
-			if err != nil {
-				return nil, err
-			}
-			date = date.In(mez)
+		reader := csv.NewReader(transform.NewReader(f, charmap.Windows1252.NewDecoder().Transformer))
+		reader.TrimLeadingSpace = true
+		reader.Comma = ';'
+		reader.ReuseRecord = true
+
+		header, err := reader.Read()
+		if err != nil {
+			yield(v2.Datapoint{}, err)
+			return
+		}
+		header = slices.Clone(header)
+
+		// the observations of the monthly and annual products cover an
+		// interval, which is described by its start and end instead of a
+		// single date
+		dateIdx := slices.Index(header, dataFieldName_Date)
+		intervalEndIdx := -1
+		if dateIdx == -1 {
+			dateIdx = slices.Index(header, dataFieldName_IntervalStart)
+			intervalEndIdx = slices.Index(header, dataFieldName_IntervalEnd)
+		}
+		if dateIdx == -1 {
+			yield(v2.Datapoint{}, errMissingDateColumn)
+			return
 		}
 
-		for idx, name := range datacolidxs {
-			p := v2.Datapoint{
-				Label:     name,
-				Timestamp: date,
-			}
+		datacolidxs := slices.DeleteFunc(dataColumns(header), func(idx int) bool {
+			return !selected(header[idx])
+		})
+		qualityColumns := qualityLevelColumns(header)
 
-			val := line[idx]
-			floatValue, err := strconv.ParseFloat(val, 64)
-			if err != nil {
-				p.Value = val
-			} else {
-				p.Value = floatValue
-			}
+		mez, err := time.LoadLocation("Etc/GMT-1")
+		if err != nil {
+			yield(v2.Datapoint{}, err)
+			return
+		}
 
-			qualityLevelStr := line[ql_idx]
-			qualityLevel := dwdTypes.QualityFlag(0)
-			qlInt, err := strconv.ParseInt(qualityLevelStr, 10, 64)
+		for {
+			line, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
 			if err != nil {
-				return nil, err
+				yield(v2.Datapoint{}, err)
+				return
 			}
 
-			err = qualityLevel.Parse(qlInt)
+			date, err := parseDataDate(line[dateIdx], mez)
 			if err != nil {
-				return nil, err
+				yield(v2.Datapoint{}, err)
+				return
 			}
 
-			p.QualityLevel = &qualityLevel
+			var interval *v2.DateTimeRange
+			if intervalEndIdx != -1 {
+				end, err := parseDataDate(line[intervalEndIdx], mez)
+				if err != nil {
+					yield(v2.Datapoint{}, err)
+					return
+				}
+				interval = &v2.DateTimeRange{Start: date, End: end}
+			}
 
-			datapoints = append(datapoints, p)
+			for _, idx := range datacolidxs {
+				name := header[idx]
+				p := v2.Datapoint{
+					Label:     name,
+					Timestamp: date,
+					Interval:  interval,
+				}
+
+				if unit, found := units[name]; found {
+					p.Unit = &unit
+				}
+
+				val := line[idx]
+				floatValue, err := strconv.ParseFloat(val, 64)
+				if err != nil {
+					p.Value = val
+				} else {
+					p.Value = floatValue
+				}
+
+				if ql_idx, found := qualityColumns[idx]; found {
+					p.QualityLevel = parseQualityLevel(line[ql_idx])
+				}
+
+				if !yield(p, nil) {
+					return
+				}
+			}
 		}
 	}
-
-	return
 }
 
 func generateMissingDatapoints(compressedFile *zip.File) (generatedDatapoints []v2.Datapoint, err error) {
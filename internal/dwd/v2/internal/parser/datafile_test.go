@@ -0,0 +1,355 @@
+package parser
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+// writeSampleArchive writes a data archive containing only the data file with
+// the content to a temporary directory and returns its path.
+func writeSampleArchive(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sample.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	dataFile, err := writer.Create("produkt_sample_00001.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dataFile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// writeFixtureArchive packs the files of the fixture directory in testdata
+// into a data archive like the ones published by the DWD and returns its path.
+func writeFixtureArchive(t *testing.T, fixture string) string {
+	t.Helper()
+
+	files, err := os.ReadDir(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), fixture+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join("testdata", fixture, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressedFile, err := writer.Create(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := compressedFile.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestQualityLevels(t *testing.T) {
+	level := func(l dwdTypes.QualityControlProcedure) *dwdTypes.QualityControlProcedure {
+		return &l
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		labels  []string
+		levels  map[string]*dwdTypes.QualityControlProcedure
+	}{
+		{
+			name: "single quality column",
+			content: "STATIONS_ID;MESS_DATUM;QN_9;TT_TU;RF_TU;eor\n" +
+				"        1;2024010100;    3;   2.5;  91.0;eor\n",
+			labels: []string{"TT_TU", "RF_TU"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"TT_TU": level(dwdTypes.QCP_Automatic),
+				"RF_TU": level(dwdTypes.QCP_Automatic),
+			},
+		},
+		{
+			name: "kl with quality columns per parameter group",
+			content: "STATIONS_ID;MESS_DATUM;QN_3;  FX;  FM;QN_4; RSK;RSKF; SDK;eor\n" +
+				"        1;20240101;   10;  12.3;   4.1;    3;   0.4;   6;   1.2;eor\n",
+			labels: []string{"FX", "FM", "RSK", "RSKF", "SDK"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"FX":   level(dwdTypes.QCP_Finished),
+				"FM":   level(dwdTypes.QCP_Finished),
+				"RSK":  level(dwdTypes.QCP_Automatic),
+				"RSKF": level(dwdTypes.QCP_Automatic),
+				"SDK":  level(dwdTypes.QCP_Automatic),
+			},
+		},
+		{
+			name: "kl daily",
+			content: "STATIONS_ID;MESS_DATUM;QN_3;  FX;  FM;QN_4; RSK;RSKF; SDK;SHK_TAG;  NM; VPM;  PM; TMK; UPM; TXK; TNK; TGK;eor\n" + //nolint:lll
+				"        1;20240101;   10;  12.3;   4.1;   10;   0.4;   6;   1.2;   0;   7.3;   8.2; 1001.2;   4.3;  86.0;   6.1;   2.0;   0.8;eor\n", //nolint:lll
+			labels: []string{"FX", "FM", "RSK", "RSKF", "SDK", "SHK_TAG", "NM", "VPM", "PM", "TMK", "UPM", "TXK", "TNK", "TGK"}, //nolint:lll
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"FX":      level(dwdTypes.QCP_Finished),
+				"FM":      level(dwdTypes.QCP_Finished),
+				"RSK":     level(dwdTypes.QCP_Finished),
+				"RSKF":    level(dwdTypes.QCP_Finished),
+				"SDK":     level(dwdTypes.QCP_Finished),
+				"SHK_TAG": level(dwdTypes.QCP_Finished),
+				"NM":      level(dwdTypes.QCP_Finished),
+				"VPM":     level(dwdTypes.QCP_Finished),
+				"PM":      level(dwdTypes.QCP_Finished),
+				"TMK":     level(dwdTypes.QCP_Finished),
+				"UPM":     level(dwdTypes.QCP_Finished),
+				"TXK":     level(dwdTypes.QCP_Finished),
+				"TNK":     level(dwdTypes.QCP_Finished),
+				"TGK":     level(dwdTypes.QCP_Finished),
+			},
+		},
+		{
+			name: "kl monthly",
+			content: "STATIONS_ID;MESS_DATUM_BEGINN;MESS_DATUM_ENDE;QN_4;MO_N;MO_TT;MO_TX;MO_TN;QN_6;MO_RR;MX_RS;eor\n" +
+				"        1;20240101;20240131;    9;   6.8;   2.9;   5.4;   0.3;    3;  71.2;  14.0;eor\n",
+			labels: []string{"MO_N", "MO_TT", "MO_TX", "MO_TN", "MO_RR", "MX_RS"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"MO_N":  level(dwdTypes.QCP_SingleParameterCorrection),
+				"MO_TT": level(dwdTypes.QCP_SingleParameterCorrection),
+				"MO_TX": level(dwdTypes.QCP_SingleParameterCorrection),
+				"MO_TN": level(dwdTypes.QCP_SingleParameterCorrection),
+				"MO_RR": level(dwdTypes.QCP_Automatic),
+				"MX_RS": level(dwdTypes.QCP_Automatic),
+			},
+		},
+		{
+			name: "wind synop with quality columns per parameter group",
+			content: "STATIONS_ID;MESS_DATUM;QN_8;FX_911;QN_9;FF;DD;eor\n" +
+				"        1;2024010100;    8;  15.0;    9;   7.2; 270;eor\n",
+			labels: []string{"FX_911", "FF", "DD"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"FX_911": level(dwdTypes.QCP_OutOfRoutine),
+				"FF":     level(dwdTypes.QCP_SingleParameterCorrection),
+				"DD":     level(dwdTypes.QCP_SingleParameterCorrection),
+			},
+		},
+		{
+			name: "missing and unknown quality levels",
+			content: "STATIONS_ID;MESS_DATUM;QN_3;FX;QN_4;RSK;eor\n" +
+				"        1;20240101; -999;  12.3;    4;   0.4;eor\n",
+			labels: []string{"FX", "RSK"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"FX":  nil,
+				"RSK": nil,
+			},
+		},
+		{
+			name: "no quality column",
+			content: "STATIONS_ID;MESS_DATUM;RS;eor\n" +
+				"        1;20240101;   0.4;eor\n",
+			labels: []string{"RS"},
+			levels: map[string]*dwdTypes.QualityControlProcedure{
+				"RS": nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := OpenArchive(writeSampleArchive(t, tt.content), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.Close()
+
+			if len(archive.Labels) != len(tt.labels) {
+				t.Fatalf("expected labels %v, got %v", tt.labels, archive.Labels)
+			}
+			for idx, label := range tt.labels {
+				if archive.Labels[idx] != label {
+					t.Fatalf("expected labels %v, got %v", tt.labels, archive.Labels)
+				}
+			}
+
+			count := 0
+			for dp, err := range archive.Datapoints() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				count++
+
+				expected, found := tt.levels[dp.Label]
+				if !found {
+					t.Fatalf("unexpected datapoint for %s", dp.Label)
+				}
+				switch {
+				case expected == nil && dp.QualityLevel != nil:
+					t.Errorf("%s: expected no quality level, got %s", dp.Label, dp.QualityLevel)
+				case expected != nil && dp.QualityLevel == nil:
+					t.Errorf("%s: expected quality level %s, got none", dp.Label, expected)
+				case expected != nil && *expected != *dp.QualityLevel:
+					t.Errorf("%s: expected quality level %s, got %s", dp.Label, expected, dp.QualityLevel)
+				}
+			}
+			if count != len(tt.levels) {
+				t.Errorf("expected %d datapoints, got %d", len(tt.levels), count)
+			}
+		})
+	}
+}
+
+func TestQualityLevelJSON(t *testing.T) {
+	for _, qn := range []string{"8", "9", "10"} {
+		level := parseQualityLevel(qn)
+		if level == nil {
+			t.Fatalf("quality level %s not parsed", qn)
+		}
+
+		encoded, err := level.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(encoded) == "null" || string(encoded) == `""` {
+			t.Errorf("quality level %s serialized as %s", qn, encoded)
+		}
+	}
+}
+
+func TestFixtureArchives(t *testing.T) {
+	level := func(l dwdTypes.QualityControlProcedure) *dwdTypes.QualityControlProcedure {
+		return &l
+	}
+	type expectedDatapoint struct {
+		timestamp time.Time
+		value     any
+		unit      string
+		level     *dwdTypes.QualityControlProcedure
+	}
+
+	tests := []struct {
+		fixture    string
+		labels     []string
+		datapoints int
+		metadata   map[string]string
+		expected   map[string][]expectedDatapoint
+	}{
+		{
+			fixture:    "kl",
+			labels:     []string{"TMK", "RSK", "FX"},
+			datapoints: 12,
+			metadata: map[string]string{
+				"TMK": "Tagesmittel der Temperatur",
+				"RSK": "tägliche Niederschlagshöhe",
+				"FX":  "Tagesmaximum Windspitze",
+			},
+			expected: map[string][]expectedDatapoint{
+				"TMK": {
+					{timestamp: time.Date(2023, 4, 19, 0, 0, 0, 0, time.UTC), value: 9.6, unit: "°C", level: level(dwdTypes.QCP_Finished)},    //nolint:lll
+					{timestamp: time.Date(2024, 10, 20, 0, 0, 0, 0, time.UTC), value: 11.9, unit: "°C", level: level(dwdTypes.QCP_Automatic)}, //nolint:lll
+				},
+				"RSK": {
+					{timestamp: time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC), value: 0.3, unit: "mm", level: level(dwdTypes.QCP_Finished)}, //nolint:lll
+				},
+				"FX": {
+					{timestamp: time.Date(2023, 4, 19, 0, 0, 0, 0, time.UTC), value: -999.0, unit: "m/s", level: level(dwdTypes.QCP_Finished)}, //nolint:lll
+					{timestamp: time.Date(2024, 10, 19, 0, 0, 0, 0, time.UTC), value: -999.0, unit: "m/s", level: nil},
+				},
+			},
+		},
+		{
+			fixture:    "wind_synop",
+			labels:     []string{"FF", "DD"},
+			datapoints: 8,
+			metadata: map[string]string{
+				"FF": "Windgeschwindigkeit",
+				"DD": "Windrichtung",
+			},
+			expected: map[string][]expectedDatapoint{
+				"FF": {
+					{timestamp: time.Date(2023, 4, 19, 1, 0, 0, 0, time.UTC), value: 2.6, unit: "m/s", level: level(dwdTypes.QCP_OutOfRoutine)},   //nolint:lll
+					{timestamp: time.Date(2024, 10, 20, 23, 0, 0, 0, time.UTC), value: -999.0, unit: "m/s", level: level(dwdTypes.QCP_Automatic)}, //nolint:lll
+				},
+				"DD": {
+					{timestamp: time.Date(2024, 10, 20, 22, 0, 0, 0, time.UTC), value: 200.0, unit: "Grad", level: level(dwdTypes.QCP_Automatic)}, //nolint:lll
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			archive, err := OpenArchive(writeFixtureArchive(t, tt.fixture), tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.Close()
+
+			if len(archive.Metadata) != len(tt.metadata) {
+				t.Fatalf("expected metadata for %d labels, got %v", len(tt.metadata), archive.Metadata)
+			}
+			for _, metadata := range archive.Metadata {
+				if metadata.Description != tt.metadata[metadata.Name] {
+					t.Errorf("%s: expected description %q, got %q", metadata.Name, tt.metadata[metadata.Name], metadata.Description)
+				}
+			}
+
+			count := 0
+			var previous time.Time
+			for dp, err := range archive.Datapoints() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				count++
+
+				if dp.Timestamp.Before(previous) {
+					t.Errorf("datapoints not ordered: %s after %s", dp.Timestamp, previous)
+				}
+				previous = dp.Timestamp
+
+				for _, expected := range tt.expected[dp.Label] {
+					if !expected.timestamp.Equal(dp.Timestamp) {
+						continue
+					}
+					if dp.Value != expected.value {
+						t.Errorf("%s at %s: expected value %v, got %v", dp.Label, dp.Timestamp, expected.value, dp.Value)
+					}
+					if dp.Unit == nil || *dp.Unit != expected.unit {
+						t.Errorf("%s at %s: expected unit %s, got %v", dp.Label, dp.Timestamp, expected.unit, dp.Unit)
+					}
+					switch {
+					case expected.level == nil && dp.QualityLevel != nil:
+						t.Errorf("%s at %s: expected no quality level, got %s", dp.Label, dp.Timestamp, dp.QualityLevel)
+					case expected.level != nil && (dp.QualityLevel == nil || *dp.QualityLevel != *expected.level):
+						t.Errorf("%s at %s: expected quality level %s, got %v", dp.Label, dp.Timestamp, expected.level, dp.QualityLevel)
+					}
+				}
+			}
+			if count != tt.datapoints {
+				t.Errorf("expected %d datapoints, got %d", tt.datapoints, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	soilFieldName_StationID = "Stationsindex"
+	soilFieldName_Date      = "Datum"
+	soilFieldName_Month     = "Monat"
+
+	// df_MonthOnly is the format of the dates in the monthly files.
+	df_MonthOnly = "200601"
+)
+
+const (
+	// SoilParameter_PotentialEvapotranspiration is the potential
+	// evapotranspiration over grass the climatic water balance is derived
+	// from.
+	SoilParameter_PotentialEvapotranspiration = "VPGB"
+
+	// SoilParameter_ClimaticWaterBalance is the label of the climatic water
+	// balance, which is not contained in the files but derived from the
+	// precipitation and the potential evapotranspiration.
+	SoilParameter_ClimaticWaterBalance = "KWB"
+)
+
+// soilFileNamePattern matches the names of the derived soil files, which end
+// with the unpadded id of the station, e.g.
+// derived_germany_soil_daily_historical_44.txt.gz.
+var soilFileNamePattern = regexp.MustCompile(`(?i)^derived_germany_soil_[a-z]+_[a-z]+_(\d+)\.txt(\.gz)?$`)
+
+// soilMissingValues are used in the derived soil files to mark missing values.
+var soilMissingValues = []float64{-99.9, -999}
+
+var errUnsupportedSoilFile = errors.New("unsupported derived soil file")
+
+// SoilParameter describes a parameter of the derived soil products.
+type SoilParameter struct {
+	Description string
+	Unit        string
+}
+
+// SoilParameters contains the descriptions and units of the parameters of the
+// derived soil products, which are calculated using the agrometeorological
+// models AMBAV and AMBETI.
+// The files only contain the names of the parameters, so parameters missing
+// from this list are returned without a description and with the unit derived
+// from their prefix.
+var SoilParameters = map[string]SoilParameter{
+	"VGSL":  {Description: "Reale Evapotranspiration über Gras und sandigem Lehm (AMBAV)", Unit: "mm"},
+	"VGLS":  {Description: "Reale Evapotranspiration über Gras und lehmigem Sand (AMBAV)", Unit: "mm"},
+	"VPGB":  {Description: "Potentielle Evapotranspiration über Gras (AMBAV)", Unit: "mm"},
+	"VPGH":  {Description: "Potentielle Evapotranspiration über Gras (Haude)", Unit: "mm"},
+	"VWSL":  {Description: "Reale Evapotranspiration über Winterweizen und sandigem Lehm (AMBAV)", Unit: "mm"},
+	"VWLS":  {Description: "Reale Evapotranspiration über Winterweizen und lehmigem Sand (AMBAV)", Unit: "mm"},
+	"VMSL":  {Description: "Reale Evapotranspiration über Mais und sandigem Lehm (AMBAV)", Unit: "mm"},
+	"VMLS":  {Description: "Reale Evapotranspiration über Mais und lehmigem Sand (AMBAV)", Unit: "mm"},
+	"TS05":  {Description: "Mittlere Bodentemperatur in 5 cm Tiefe unter unbewachsenem Boden (AMBETI)", Unit: "°C"},
+	"TS10":  {Description: "Mittlere Bodentemperatur in 10 cm Tiefe unter unbewachsenem Boden (AMBETI)", Unit: "°C"},
+	"TS20":  {Description: "Mittlere Bodentemperatur in 20 cm Tiefe unter unbewachsenem Boden (AMBETI)", Unit: "°C"},
+	"TS50":  {Description: "Mittlere Bodentemperatur in 50 cm Tiefe unter unbewachsenem Boden (AMBETI)", Unit: "°C"},
+	"TS100": {Description: "Mittlere Bodentemperatur in 100 cm Tiefe unter unbewachsenem Boden (AMBETI)", Unit: "°C"},
+	"ZFUMI": {Description: "Frosteindringtiefe um 12 UTC (AMBETI)", Unit: "cm"},
+	"BF10":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 0 bis 10 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BF20":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 10 bis 20 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BF30":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 20 bis 30 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BF40":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 30 bis 40 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BF50":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 40 bis 50 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BF60":  {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 50 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFGSL": {Description: "Bodenfeuchte unter Gras und sandigem Lehm in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFGLS": {Description: "Bodenfeuchte unter Gras und lehmigem Sand in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFWSL": {Description: "Bodenfeuchte unter Winterweizen und sandigem Lehm in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFWLS": {Description: "Bodenfeuchte unter Winterweizen und lehmigem Sand in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFMSL": {Description: "Bodenfeuchte unter Mais und sandigem Lehm in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+	"BFMLS": {Description: "Bodenfeuchte unter Mais und lehmigem Sand in 0 bis 60 cm Tiefe (AMBAV)", Unit: "% nFK"},
+}
+
+// soilUnitPrefixes maps the prefixes of the parameter names to the units of
+// the parameters, which is used for parameters missing from [SoilParameters].
+var soilUnitPrefixes = [][2]string{
+	{"BF", "% nFK"},
+	{"TS", "°C"},
+	{"V", "mm"},
+}
+
+// ParseSoilFileName returns the id of the station contained in the derived
+// soil file with the name.
+// The id is zero-padded to match the ids of the climate observations.
+func ParseSoilFileName(name string) (stationID string, ok bool) {
+	match := soilFileNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", false
+	}
+	id, err := strconv.Atoi(match[1])
+	if err != nil {
+		return "", false
+	}
+	return padStationID(id), true
+}
+
+// soilParameter returns the description and unit of the parameter.
+func soilParameter(name string) SoilParameter {
+	if parameter, found := SoilParameters[name]; found {
+		return parameter
+	}
+	for _, prefix := range soilUnitPrefixes {
+		if strings.HasPrefix(name, prefix[0]) {
+			return SoilParameter{Unit: prefix[1]}
+		}
+	}
+	return SoilParameter{}
+}
+
+// OpenSoilObservations reads the derived soil parameters from the (optionally
+// gzip compressed) file at the path.
+// Only the parameters accepted by the include function are read, which allows
+// splitting the files into several products.
+// If labels are supplied, only the parameters with these labels are read.
+// The datapoints of the monthly files are stamped with the start of the month
+// and carry the month as their interval.
+func OpenSoilObservations(path string, include func(parameter string) bool, labels []string) (*Archive, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(f)
+	var r io.Reader = buffered
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) { //nolint:mnd
+		decompressed, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer decompressed.Close()
+		r = decompressed
+	}
+
+	reader := csv.NewReader(transform.NewReader(r, charmap.Windows1252.NewDecoder().Transformer))
+	reader.TrimLeadingSpace = true
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for idx := range header {
+		header[idx] = strings.TrimSpace(header[idx])
+	}
+
+	dateIdx, monthly := slices.Index(header, soilFieldName_Date), false
+	if dateIdx == -1 {
+		dateIdx, monthly = slices.Index(header, soilFieldName_Month), true
+	}
+	if dateIdx == -1 {
+		return nil, errUnsupportedSoilFile
+	}
+
+	archive := &Archive{selectedLabels: labels}
+	var columns []int
+	for idx, name := range header {
+		switch name {
+		case "", soilFieldName_StationID, soilFieldName_Date, soilFieldName_Month:
+			continue
+		}
+		if !include(name) || !archive.selected(name) {
+			continue
+		}
+
+		parameter := soilParameter(name)
+		columns = append(columns, idx)
+		archive.Labels = append(archive.Labels, name)
+		archive.Metadata = append(archive.Metadata, v2.FieldMetadata{
+			Name:        name,
+			Description: parameter.Description,
+			Unit:        parameter.Unit,
+		})
+	}
+
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) <= dateIdx {
+			continue
+		}
+
+		var timestamp time.Time
+		var interval *v2.DateTimeRange
+		dateString := strings.TrimSpace(line[dateIdx])
+		switch {
+		case monthly || len(dateString) == len(df_MonthOnly):
+			timestamp, err = time.Parse(df_MonthOnly, dateString)
+			interval = &v2.DateTimeRange{Start: timestamp, End: timestamp.AddDate(0, 1, -1)}
+		default:
+			timestamp, err = time.Parse(df_DayOnly, dateString)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for idx, column := range columns {
+			metadata := &archive.Metadata[idx]
+			if metadata.ValidFrom.IsZero() || timestamp.Before(metadata.ValidFrom) {
+				metadata.ValidFrom = timestamp
+			}
+			if timestamp.After(metadata.ValidUntil) {
+				metadata.ValidUntil = timestamp
+			}
+
+			dp := v2.Datapoint{
+				Label:     metadata.Name,
+				Timestamp: timestamp,
+				Interval:  interval,
+			}
+			if metadata.Unit != "" {
+				dp.Unit = &metadata.Unit
+			}
+			if column < len(line) {
+				value, err := strconv.ParseFloat(strings.TrimSpace(line[column]), 64)
+				if err == nil && !slices.Contains(soilMissingValues, value) {
+					dp.Value = value
+				}
+			}
+			archive.bufferedDatapoints = append(archive.bufferedDatapoints, dp)
+		}
+	}
+
+	return archive, nil
+}
+
+// ClimaticWaterBalance derives the climatic water balance from the potential
+// evapotranspiration over grass read from a derived soil file and the
+// precipitation heights (in mm) observed at the station, which are keyed by
+// the timestamps of the datapoints in UTC.
+// The climatic water balance is the difference of the precipitation and the
+// potential evapotranspiration, so datapoints missing either value are
+// returned without a value.
+func ClimaticWaterBalance(evapotranspiration *Archive, precipitation map[time.Time]float64) *Archive {
+	metadata := v2.FieldMetadata{
+		Name:        SoilParameter_ClimaticWaterBalance,
+		Description: "Klimatische Wasserbilanz (Niederschlagshöhe abzüglich der potentiellen Evapotranspiration über Gras (AMBAV))", //nolint:lll
+		Unit:        "mm",
+	}
+	if idx := slices.Index(evapotranspiration.Labels, SoilParameter_PotentialEvapotranspiration); idx != -1 {
+		metadata.ValidFrom = evapotranspiration.Metadata[idx].ValidFrom
+		metadata.ValidUntil = evapotranspiration.Metadata[idx].ValidUntil
+	}
+
+	archive := &Archive{
+		Metadata: []v2.FieldMetadata{metadata},
+		Labels:   []string{metadata.Name},
+	}
+	for _, dp := range evapotranspiration.bufferedDatapoints {
+		if dp.Label != SoilParameter_PotentialEvapotranspiration {
+			continue
+		}
+
+		balance := v2.Datapoint{
+			Label:     metadata.Name,
+			Timestamp: dp.Timestamp,
+			Interval:  dp.Interval,
+			Unit:      &archive.Metadata[0].Unit,
+		}
+		potential, valid := dp.Value.(float64)
+		if height, found := precipitation[dp.Timestamp.UTC()]; valid && found {
+			balance.Value = height - potential
+		}
+		archive.bufferedDatapoints = append(archive.bufferedDatapoints, balance)
+	}
+	return archive
+}
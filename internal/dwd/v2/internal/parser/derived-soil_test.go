@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClimaticWaterBalance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "derived_germany_soil_daily_recent_44.txt")
+	content := "Stationsindex;Datum;VGSL;VPGB;BFGSL\n" +
+		"44;20240101;0.4;0.5;98\n" +
+		"44;20240102;0.6;1.2;97\n" +
+		"44;20240103;0.7;-99.9;96\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	evapotranspiration, err := OpenSoilObservations(path, func(parameter string) bool {
+		return parameter == SoilParameter_PotentialEvapotranspiration
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(day int) time.Time {
+		return time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC)
+	}
+	// the second day has no precipitation, the third day no evapotranspiration
+	archive := ClimaticWaterBalance(evapotranspiration, map[time.Time]float64{
+		day(1): 3.5,
+		day(3): 1,
+	})
+
+	if len(archive.Labels) != 1 || archive.Labels[0] != SoilParameter_ClimaticWaterBalance {
+		t.Fatalf("unexpected labels %v", archive.Labels)
+	}
+	if !archive.Metadata[0].ValidFrom.Equal(day(1)) || !archive.Metadata[0].ValidUntil.Equal(day(3)) {
+		t.Errorf("unexpected range of the metadata %+v", archive.Metadata[0])
+	}
+
+	expected := []any{3.0, nil, nil}
+	idx := 0
+	for dp, err := range archive.Datapoints() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idx >= len(expected) {
+			t.Fatalf("unexpected datapoint %+v", dp)
+		}
+		if !dp.Timestamp.Equal(day(idx+1)) || dp.Value != expected[idx] {
+			t.Errorf("expected %v at %s, got %v at %s", expected[idx], day(idx+1), dp.Value, dp.Timestamp)
+		}
+		if dp.Unit == nil || *dp.Unit != "mm" {
+			t.Errorf("expected the unit mm, got %v", dp.Unit)
+		}
+		idx++
+	}
+	if idx != len(expected) {
+		t.Errorf("expected %d datapoints, got %d", len(expected), idx)
+	}
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+const (
+	fileNameSuffix_Historical = "hist"
+	fileNameSuffix_Recent     = "akt"
+	fileNameSuffix_Now        = "now"
+)
+
+// stationIDLength is the length of the zero-padded station ids used in the
+// names of the archives.
+const stationIDLength = 5
+
+var errMalformedFileName = errors.New("malformed archive name")
+
+// FileName contains the information encoded into the name of an archive on
+// the OpenData Portal.
+// The names follow the grammar
+//
+//	<prefix>_<product>_<station>[_<begin>_<end>]_<suffix>.zip
+//
+// e.g. stundenwerte_TU_00044_19690101_20231231_hist.zip or
+// 10minutenwerte_extrema_wind_00044_now.zip.
+type FileName struct {
+	// Prefix contains the granularity of the archive in German
+	// (e.g. stundenwerte, tageswerte).
+	Prefix string
+
+	// Product contains the code of the product, which may contain underscores
+	// itself (e.g. TU, KL, extrema_wind).
+	Product string
+
+	// StationID contains the zero-padded id of the station.
+	StationID string
+
+	// Start and End contain the first and the last day covered by the archive.
+	// Both are zero if the range is not encoded into the name, which is the
+	// case for the recent and current archives.
+	Start, End time.Time
+
+	// Suffix contains the unparsed suffix of the name (e.g. hist, akt, now).
+	Suffix string
+
+	// Period contains the period described by the suffix.
+	Period dwdTypes.Period
+}
+
+// ParseFileName parses the name of an archive.
+// Names not following the grammar of the archives (e.g. description files)
+// are rejected with an error.
+func ParseFileName(name string) (FileName, error) {
+	name = path.Base(strings.TrimSpace(name))
+	stem, isArchive := strings.CutSuffix(name, ".zip")
+	if !isArchive {
+		return FileName{}, errMalformedFileName
+	}
+
+	parts := strings.Split(stem, "_")
+	// the smallest valid name contains a prefix, product, station and suffix
+	if len(parts) < 4 { //nolint:mnd
+		return FileName{}, errMalformedFileName
+	}
+
+	var f FileName
+	f.Prefix = parts[0]
+
+	// the product may contain underscores, so the name is parsed from the right
+	last := len(parts) - 1
+	f.Suffix = parts[last]
+	switch f.Suffix {
+	case fileNameSuffix_Historical:
+		f.Period = dwdTypes.Period_Historical
+	case fileNameSuffix_Recent:
+		f.Period = dwdTypes.Period_Recent
+	case fileNameSuffix_Now:
+		f.Period = dwdTypes.Period_Now
+	}
+	last--
+
+	if last >= 4 && isDigits(parts[last], len(df_DayOnly)) && isDigits(parts[last-1], len(df_DayOnly)) { //nolint:mnd
+		var err error
+		f.Start, err = time.Parse(df_DayOnly, parts[last-1])
+		if err != nil {
+			return FileName{}, errMalformedFileName
+		}
+		f.End, err = time.Parse(df_DayOnly, parts[last])
+		if err != nil {
+			return FileName{}, errMalformedFileName
+		}
+		last -= 2
+	}
+
+	if !isDigits(parts[last], stationIDLength) || last < 2 { //nolint:mnd
+		return FileName{}, errMalformedFileName
+	}
+	f.StationID = parts[last]
+	f.Product = strings.Join(parts[1:last], "_")
+
+	return f, nil
+}
+
+// Overlaps reports if the archive may contain datapoints between start and
+// end (both inclusive).
+// A zero start or end leaves the range open on that side, and archives without
+// an encoded range are expected to overlap every range.
+func (f FileName) Overlaps(start, end time.Time) bool {
+	if f.Start.IsZero() || f.End.IsZero() {
+		return true
+	}
+
+	if !end.IsZero() && f.Start.After(end) {
+		return false
+	}
+
+	// the end date is covered completely by the archive
+	if !start.IsZero() && !f.End.AddDate(0, 0, 1).After(start) {
+		return false
+	}
+
+	return true
+}
+
+// isDigits checks if the string consists of exactly length digits.
+func isDigits(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+func TestParseFileName(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		expected FileName
+		err      bool
+	}{
+		{
+			name: "stundenwerte_TU_00044_19690101_20231231_hist.zip",
+			expected: FileName{
+				Prefix: "stundenwerte", Product: "TU", StationID: "00044",
+				Start: date(1969, 1, 1), End: date(2023, 12, 31),
+				Suffix: "hist", Period: dwdTypes.Period_Historical,
+			},
+		},
+		{
+			name: "tageswerte_KL_00044_akt.zip",
+			expected: FileName{
+				Prefix: "tageswerte", Product: "KL", StationID: "00044",
+				Suffix: "akt", Period: dwdTypes.Period_Recent,
+			},
+		},
+		{
+			name: "10minutenwerte_extrema_wind_00044_now.zip",
+			expected: FileName{
+				Prefix: "10minutenwerte", Product: "extrema_wind", StationID: "00044",
+				Suffix: "now", Period: dwdTypes.Period_Now,
+			},
+		},
+		{
+			name: "10minutenwerte_extrema_wind_00044_20200101_20231231_hist.zip",
+			expected: FileName{
+				Prefix: "10minutenwerte", Product: "extrema_wind", StationID: "00044",
+				Start: date(2020, 1, 1), End: date(2023, 12, 31),
+				Suffix: "hist", Period: dwdTypes.Period_Historical,
+			},
+		},
+		{
+			// the historical 1-minute precipitation is split into yearly
+			// folders containing an archive per month
+			name: "1_minute/precipitation/historical/2021/1minutenwerte_nieder_00044_20210201_20210228_hist.zip",
+			expected: FileName{
+				Prefix: "1minutenwerte", Product: "nieder", StationID: "00044",
+				Start: date(2021, 2, 1), End: date(2021, 2, 28),
+				Suffix: "hist", Period: dwdTypes.Period_Historical,
+			},
+		},
+		{
+			name: "1minutenwerte_nieder_00003_19930428_19991231_hist.zip",
+			expected: FileName{
+				Prefix: "1minutenwerte", Product: "nieder", StationID: "00003",
+				Start: date(1993, 4, 28), End: date(1999, 12, 31),
+				Suffix: "hist", Period: dwdTypes.Period_Historical,
+			},
+		},
+		{
+			name: "1minutenwerte_nieder_00044_now.zip",
+			expected: FileName{
+				Prefix: "1minutenwerte", Product: "nieder", StationID: "00044",
+				Suffix: "now", Period: dwdTypes.Period_Now,
+			},
+		},
+		{
+			name: "  monatswerte_KL_00044_18810101_20231231_hist.zip ",
+			expected: FileName{
+				Prefix: "monatswerte", Product: "KL", StationID: "00044",
+				Start: date(1881, 1, 1), End: date(2023, 12, 31),
+				Suffix: "hist", Period: dwdTypes.Period_Historical,
+			},
+		},
+		{name: "KL_Tageswerte_Beschreibung_Stationen.txt", err: true},
+		{name: "DESCRIPTION_obsgermany_climate_daily_kl_en.pdf", err: true},
+		{name: "tageswerte_KL_44_akt.zip", err: true},
+		{name: "tageswerte_00044_akt.zip", err: true},
+		{name: "tageswerte_KL_00044_20241301_20241231_hist.zip", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFileName(tt.name)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", f)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.Prefix != tt.expected.Prefix || f.Product != tt.expected.Product ||
+				f.StationID != tt.expected.StationID || f.Suffix != tt.expected.Suffix ||
+				f.Period != tt.expected.Period || !f.Start.Equal(tt.expected.Start) || !f.End.Equal(tt.expected.End) {
+				t.Errorf("expected %+v, got %+v", tt.expected, f)
+			}
+		})
+	}
+}
+
+func TestFileNameOverlaps(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	monthly, err := ParseFileName("1minutenwerte_nieder_00044_20210201_20210228_hist.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := ParseFileName("tageswerte_KL_00044_akt.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		file     FileName
+		start    time.Time
+		end      time.Time
+		overlaps bool
+	}{
+		{name: "open range", file: monthly, overlaps: true},
+		{name: "range inside the archive", file: monthly, start: date(2021, 2, 10), end: date(2021, 2, 11), overlaps: true},
+		{name: "range around the archive", file: monthly, start: date(2021, 1, 1), end: date(2021, 12, 31), overlaps: true},
+		{name: "range before the archive", file: monthly, start: date(2021, 1, 1), end: date(2021, 1, 31), overlaps: false},
+		{name: "range ending on the first day", file: monthly, end: date(2021, 2, 1), overlaps: true},
+		{name: "range after the archive", file: monthly, start: date(2021, 3, 1), overlaps: false},
+		// the end date is covered until midnight of the following day
+		{name: "range starting during the last day", file: monthly, start: date(2021, 2, 28).Add(23 * time.Hour), overlaps: true}, //nolint:lll
+		{name: "range ending before the archive", file: monthly, end: date(2021, 1, 31), overlaps: false},
+		{name: "archive without range", file: recent, start: date(1900, 1, 1), end: date(1900, 1, 2), overlaps: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.Overlaps(tt.start, tt.end); got != tt.overlaps {
+				t.Errorf("expected %t, got %t", tt.overlaps, got)
+			}
+		})
+	}
+}
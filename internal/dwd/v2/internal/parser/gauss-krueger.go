@@ -0,0 +1,115 @@
+package parser
+
+import "math"
+
+// The Gauss-Krüger coordinates used by the DWD grids are based on the DHDN
+// datum using the Bessel ellipsoid and the transverse mercator projection of
+// the third zone (EPSG:31467).
+const (
+	besselSemiMajorAxis     = 6377397.155
+	besselInverseFlattening = 299.1528128
+
+	wgs84SemiMajorAxis     = 6378137.0
+	wgs84InverseFlattening = 298.257223563
+
+	gaussKruegerCentralMeridian = 9.0
+	gaussKruegerFalseEasting    = 3500000.0
+)
+
+// dhdnToWGS84 contains the parameters of the Helmert transformation from the
+// DHDN datum into WGS84 (translations in meters, rotations in arc seconds and
+// the scale in ppm) as used for EPSG:31467.
+var dhdnToWGS84 = [7]float64{598.1, 73.7, 418.2, 0.202, 0.045, -2.455, 6.7}
+
+// GaussKruegerZone3 converts a WGS84 coordinate into the easting and northing
+// of the third Gauss-Krüger zone.
+func GaussKruegerZone3(longitude, latitude float64) (easting, northing float64) {
+	x, y, z := geodeticToCartesian(longitude, latitude, wgs84SemiMajorAxis, wgs84InverseFlattening)
+	x, y, z = inverseHelmert(x, y, z, dhdnToWGS84)
+	longitude, latitude = cartesianToGeodetic(x, y, z, besselSemiMajorAxis, besselInverseFlattening)
+	return transverseMercator(longitude, latitude, besselSemiMajorAxis, besselInverseFlattening,
+		gaussKruegerCentralMeridian, gaussKruegerFalseEasting)
+}
+
+// geodeticToCartesian converts a geodetic coordinate on the surface of the
+// ellipsoid into earth-centered cartesian coordinates.
+func geodeticToCartesian(longitude, latitude, a, inverseFlattening float64) (x, y, z float64) {
+	f := 1 / inverseFlattening
+	e2 := f * (2 - f) //nolint:mnd
+	phi, lambda := radians(latitude), radians(longitude)
+
+	n := a / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	return n * math.Cos(phi) * math.Cos(lambda),
+		n * math.Cos(phi) * math.Sin(lambda),
+		n * (1 - e2) * math.Sin(phi)
+}
+
+// cartesianToGeodetic converts earth-centered cartesian coordinates into a
+// geodetic coordinate on the ellipsoid.
+func cartesianToGeodetic(x, y, z, a, inverseFlattening float64) (longitude, latitude float64) {
+	f := 1 / inverseFlattening
+	e2 := f * (2 - f) //nolint:mnd
+	p := math.Hypot(x, y)
+
+	phi := math.Atan2(z, p*(1-e2))
+	for range 10 {
+		n := a / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+		h := p/math.Cos(phi) - n
+		phi = math.Atan2(z, p*(1-e2*n/(n+h)))
+	}
+
+	return degrees(math.Atan2(y, x)), degrees(phi)
+}
+
+// inverseHelmert reverses the Helmert transformation with the parameters.
+// The rotations are small enough to invert the rotation matrix by
+// transposing it.
+func inverseHelmert(x, y, z float64, parameters [7]float64) (float64, float64, float64) {
+	const arcSecond = math.Pi / (180 * 3600)
+	rx, ry, rz := parameters[3]*arcSecond, parameters[4]*arcSecond, parameters[5]*arcSecond
+	scale := 1 + parameters[6]*1e-6
+
+	x, y, z = (x-parameters[0])/scale, (y-parameters[1])/scale, (z-parameters[2])/scale
+	return x + rz*y - ry*z,
+		-rz*x + y + rx*z,
+		ry*x - rx*y + z
+}
+
+// transverseMercator projects a geodetic coordinate using the transverse
+// mercator projection with a scale factor of 1.
+func transverseMercator(longitude, latitude, a, inverseFlattening, centralMeridian, falseEasting float64) (easting, northing float64) { //nolint:lll
+	f := 1 / inverseFlattening
+	e2 := f * (2 - f) //nolint:mnd
+	e4, e6 := e2*e2, e2*e2*e2
+	ep2 := e2 / (1 - e2)
+
+	phi := radians(latitude)
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+
+	n := a / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := math.Tan(phi) * math.Tan(phi)
+	c := ep2 * cosPhi * cosPhi
+	l := radians(longitude-centralMeridian) * cosPhi
+
+	// meridian arc length from the equator
+	m := a * ((1-e2/4-3*e4/64-5*e6/256)*phi - //nolint:mnd
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) + //nolint:mnd
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) - //nolint:mnd
+		(35*e6/3072)*math.Sin(6*phi)) //nolint:mnd
+
+	easting = falseEasting + n*(l+
+		(1-t+c)*math.Pow(l, 3)/6+ //nolint:mnd
+		(5-18*t+t*t+72*c-58*ep2)*math.Pow(l, 5)/120) //nolint:mnd
+	northing = m + n*math.Tan(phi)*(l*l/2+ //nolint:mnd
+		(5-t+9*c+4*c*c)*math.Pow(l, 4)/24+ //nolint:mnd
+		(61-58*t+t*t+600*c-330*ep2)*math.Pow(l, 6)/720) //nolint:mnd
+	return easting, northing
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180 //nolint:mnd
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi //nolint:mnd
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGaussKruegerZone3(t *testing.T) {
+	// the reference coordinates of EPSG:31467 were calculated using the
+	// position vector transformation of EPSG:1777 and the Krüger series of
+	// the transverse mercator projection
+	tests := []struct {
+		longitude, latitude float64
+		easting, northing   float64
+	}{
+		{longitude: 9, latitude: 51, easting: 3500073.575, northing: 5651645.883},
+		{longitude: 6.5, latitude: 50.5, easting: 3322710.988, northing: 5599009.266},
+		{longitude: 11.5, latitude: 48.5, easting: 3684822.637, northing: 5376606.217},
+		{longitude: 8.5, latitude: 54.5, easting: 3467676.561, northing: 6041245.762},
+	}
+
+	for _, tt := range tests {
+		easting, northing := GaussKruegerZone3(tt.longitude, tt.latitude)
+		if math.Abs(easting-tt.easting) > 0.5 || math.Abs(northing-tt.northing) > 0.5 {
+			t.Errorf("%v/%v: expected %.3f/%.3f, got %.3f/%.3f", tt.longitude, tt.latitude,
+				tt.easting, tt.northing, easting, northing)
+		}
+	}
+}
+
+func TestDHDNRoundTrip(t *testing.T) {
+	// the inverse of the Helmert transformation reverses the transformation of
+	// DHDN coordinates into WGS84
+	longitude, latitude := 10.0, 52.0
+	x, y, z := geodeticToCartesian(longitude, latitude, besselSemiMajorAxis, besselInverseFlattening)
+
+	const arcSecond = math.Pi / (180 * 3600)
+	rx, ry, rz := dhdnToWGS84[3]*arcSecond, dhdnToWGS84[4]*arcSecond, dhdnToWGS84[5]*arcSecond
+	scale := 1 + dhdnToWGS84[6]*1e-6
+	wx := dhdnToWGS84[0] + scale*(x-rz*y+ry*z)
+	wy := dhdnToWGS84[1] + scale*(rz*x+y-rx*z)
+	wz := dhdnToWGS84[2] + scale*(-ry*x+rx*y+z)
+
+	x, y, z = inverseHelmert(wx, wy, wz, dhdnToWGS84)
+	gotLongitude, gotLatitude := cartesianToGeodetic(x, y, z, besselSemiMajorAxis, besselInverseFlattening)
+	if math.Abs(gotLongitude-longitude) > 1e-8 || math.Abs(gotLatitude-latitude) > 1e-8 {
+		t.Errorf("expected %v/%v, got %v/%v", longitude, latitude, gotLongitude, gotLatitude)
+	}
+}
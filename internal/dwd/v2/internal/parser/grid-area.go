@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"errors"
+	"math"
+
+	"github.com/twpayne/go-geom"
+)
+
+var errUnsupportedGeometry = errors.New("the area needs to be a point, polygon or multipolygon")
+
+// ErrAreaOutsideGrid is returned if an area does not cover any cell of a
+// grid.
+var ErrAreaOutsideGrid = errors.New("the area is not covered by the grid")
+
+// GridArea is the area a timeseries is extracted from a grid for.
+// The area is either a single point, which selects the grid cell containing
+// it, or a set of polygons, which select all grid cells whose centers are
+// located in one of the polygons.
+// All coordinates are given as longitude and latitude in WGS84.
+type GridArea struct {
+	point    *geom.Point
+	polygons [][]*geom.LinearRing
+}
+
+// NewGridArea creates the area from a point, polygon or multipolygon.
+func NewGridArea(geometry geom.T) (GridArea, error) {
+	switch g := geometry.(type) {
+	case *geom.Point:
+		return GridArea{point: g}, nil
+	case *geom.Polygon:
+		return GridArea{polygons: [][]*geom.LinearRing{polygonRings(g)}}, nil
+	case *geom.MultiPolygon:
+		var area GridArea
+		for idx := range g.NumPolygons() {
+			area.polygons = append(area.polygons, polygonRings(g.Polygon(idx)))
+		}
+		return area, nil
+	default:
+		return GridArea{}, errUnsupportedGeometry
+	}
+}
+
+// Cells returns the indices of the grid cells covered by the area in a grid
+// with the columns and rows.
+// The projection converts a coordinate into the fractional column and row of
+// the grid, so that the cell (column, row) covers the range
+// [column, column+1) × [row, row+1) and has the index row*columns+column.
+// Polygons which are too small to contain the center of a grid cell select
+// the cell containing their first vertex.
+func (a GridArea) Cells(columns, rows int, project func(longitude, latitude float64) (column, row float64)) []int {
+	inGrid := func(column, row int) bool {
+		return column >= 0 && column < columns && row >= 0 && row < rows
+	}
+
+	if a.point != nil {
+		column, row := project(a.point.X(), a.point.Y())
+		c, r := int(math.Floor(column)), int(math.Floor(row))
+		if !inGrid(c, r) {
+			return nil
+		}
+		return []int{r*columns + c}
+	}
+
+	var cells []int
+	selected := make(map[int]bool)
+	for _, rings := range a.polygons {
+		projected := make([][][2]float64, len(rings))
+		minColumn, minRow := math.Inf(1), math.Inf(1)
+		maxColumn, maxRow := math.Inf(-1), math.Inf(-1)
+		for ringIdx, ring := range rings {
+			for _, coordinate := range ring.Coords() {
+				column, row := project(coordinate.X(), coordinate.Y())
+				projected[ringIdx] = append(projected[ringIdx], [2]float64{column, row})
+				minColumn, maxColumn = math.Min(minColumn, column), math.Max(maxColumn, column)
+				minRow, maxRow = math.Min(minRow, row), math.Max(maxRow, row)
+			}
+		}
+		if len(projected) == 0 || len(projected[0]) == 0 {
+			continue
+		}
+
+		found := false
+		for r := max(int(math.Floor(minRow)), 0); r <= min(int(math.Floor(maxRow)), rows-1); r++ {
+			for c := max(int(math.Floor(minColumn)), 0); c <= min(int(math.Floor(maxColumn)), columns-1); c++ {
+				if !containsPoint(projected, float64(c)+0.5, float64(r)+0.5) { //nolint:mnd
+					continue
+				}
+				found = true
+				if idx := r*columns + c; !selected[idx] {
+					selected[idx] = true
+					cells = append(cells, idx)
+				}
+			}
+		}
+
+		if !found {
+			first := projected[0][0]
+			c, r := int(math.Floor(first[0])), int(math.Floor(first[1]))
+			if idx := r*columns + c; inGrid(c, r) && !selected[idx] {
+				selected[idx] = true
+				cells = append(cells, idx)
+			}
+		}
+	}
+	return cells
+}
+
+// polygonRings returns the exterior and interior rings of the polygon.
+func polygonRings(polygon *geom.Polygon) []*geom.LinearRing {
+	rings := make([]*geom.LinearRing, polygon.NumLinearRings())
+	for idx := range rings {
+		rings[idx] = polygon.LinearRing(idx)
+	}
+	return rings
+}
+
+// PolygonContains checks if the point is located in the polygon, excluding
+// the holes of the polygon.
+func PolygonContains(polygon *geom.Polygon, x, y float64) bool {
+	rings := make([][][2]float64, 0, polygon.NumLinearRings())
+	for _, ring := range polygonRings(polygon) {
+		coordinates := make([][2]float64, 0, ring.NumCoords())
+		for _, coordinate := range ring.Coords() {
+			coordinates = append(coordinates, [2]float64{coordinate.X(), coordinate.Y()})
+		}
+		rings = append(rings, coordinates)
+	}
+	return containsPoint(rings, x, y)
+}
+
+// containsPoint checks if the point is located in the polygon described by
+// the rings using the even-odd rule, which excludes the holes of the polygon.
+func containsPoint(rings [][][2]float64, x, y float64) bool {
+	inside := false
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a[1] > y) != (b[1] > y) && x < (b[0]-a[0])*(y-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// scaleValue multiplies the value stored in a grid with the factor of the
+// grid.
+// Factors below one are applied by dividing by their inverse, which avoids the
+// representation errors of the decimal fractions (e.g. 0.1).
+func scaleValue(value, factor float64) float64 {
+	if factor > 0 && factor < 1 {
+		return value / math.Round(1/factor)
+	}
+	return value * factor
+}
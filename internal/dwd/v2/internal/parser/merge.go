@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"iter"
+
+	v2 "microservice/types/v2"
+)
+
+// MergeDatapoints merges the datapoints of multiple iterators into a single
+// iterator ordered by the timestamps of the datapoints.
+// Each of the iterators needs to yield its datapoints ordered by their
+// timestamps already.
+// If multiple datapoints share a timestamp, they are yielded in the order of
+// the iterators.
+func MergeDatapoints(sequences ...iter.Seq2[v2.Datapoint, error]) iter.Seq2[v2.Datapoint, error] {
+	if len(sequences) == 1 {
+		return sequences[0]
+	}
+
+	return func(yield func(v2.Datapoint, error) bool) {
+		type head struct {
+			next      func() (v2.Datapoint, error, bool)
+			datapoint v2.Datapoint
+			valid     bool
+		}
+
+		heads := make([]head, len(sequences))
+		for idx, sequence := range sequences {
+			next, stop := iter.Pull2(sequence)
+			defer stop()
+
+			heads[idx].next = next
+		}
+
+		advance := func(h *head) bool {
+			datapoint, err, valid := h.next()
+			if err != nil {
+				yield(v2.Datapoint{}, err)
+				return false
+			}
+			h.datapoint, h.valid = datapoint, valid
+			return true
+		}
+
+		for idx := range heads {
+			if !advance(&heads[idx]) {
+				return
+			}
+		}
+
+		for {
+			selected := -1
+			for idx, h := range heads {
+				if !h.valid {
+					continue
+				}
+				if selected == -1 || h.datapoint.Timestamp.Before(heads[selected].datapoint.Timestamp) {
+					selected = idx
+				}
+			}
+
+			if selected == -1 {
+				return
+			}
+
+			if !yield(heads[selected].datapoint, nil) {
+				return
+			}
+
+			if !advance(&heads[selected]) {
+				return
+			}
+		}
+	}
+}
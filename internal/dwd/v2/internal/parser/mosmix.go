@@ -0,0 +1,399 @@
+package parser
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	mosmixElement_IssueTime = "IssueTime"
+	mosmixElement_TimeStep  = "TimeStep"
+	mosmixElement_Placemark = "Placemark"
+
+	// mosmixMissingValue is used in the forecasts to mark missing values.
+	mosmixMissingValue = "-"
+)
+
+var (
+	errMosmixDocumentMissing  = errors.New("the archive contains no kml document")
+	errMosmixIssueTimeMissing = errors.New("the kml document contains no issue time")
+)
+
+// MosmixElement describes a forecast element of the MOSMIX forecasts.
+type MosmixElement struct {
+	Description string
+	Unit        string
+}
+
+// MosmixElements contains the descriptions and units of the commonly used
+// MOSMIX forecast elements.
+// The forecasts only contain the names of the elements, so elements missing
+// from this list are returned without a description and unit.
+var MosmixElements = map[string]MosmixElement{
+	"TTT":    {Description: "Temperature 2m above surface", Unit: "K"},
+	"Td":     {Description: "Dewpoint 2m above surface", Unit: "K"},
+	"TX":     {Description: "Maximum temperature within the last 12 hours", Unit: "K"},
+	"TN":     {Description: "Minimum temperature within the last 12 hours", Unit: "K"},
+	"TG":     {Description: "Minimum surface temperature at 5cm within the last 12 hours", Unit: "K"},
+	"T5cm":   {Description: "Temperature 5cm above surface", Unit: "K"},
+	"DD":     {Description: "Wind direction", Unit: "°"},
+	"FF":     {Description: "Wind speed", Unit: "m/s"},
+	"FX1":    {Description: "Maximum wind gust within the last hour", Unit: "m/s"},
+	"FX3":    {Description: "Maximum wind gust within the last 3 hours", Unit: "m/s"},
+	"FXh":    {Description: "Maximum wind gust within the last 12 hours", Unit: "m/s"},
+	"PPPP":   {Description: "Surface pressure, reduced", Unit: "Pa"},
+	"N":      {Description: "Total cloud cover", Unit: "%"},
+	"Neff":   {Description: "Effective cloud cover", Unit: "%"},
+	"Nh":     {Description: "High cloud cover (>7 km)", Unit: "%"},
+	"Nm":     {Description: "Midlevel cloud cover (2-7 km)", Unit: "%"},
+	"Nl":     {Description: "Low cloud cover (lower than 2 km)", Unit: "%"},
+	"RR1c":   {Description: "Total precipitation during the last hour consistent with significant weather", Unit: "kg/m²"},
+	"RR3c":   {Description: "Total precipitation during the last 3 hours consistent with significant weather", Unit: "kg/m²"},
+	"RRS1c":  {Description: "Snow-Rain-Equivalent during the last hour", Unit: "kg/m²"},
+	"RRS3c":  {Description: "Snow-Rain-Equivalent during the last 3 hours", Unit: "kg/m²"},
+	"R101":   {Description: "Probability of precipitation > 0.1 mm during the last hour", Unit: "%"},
+	"ww":     {Description: "Significant weather", Unit: ""},
+	"VV":     {Description: "Visibility", Unit: "m"},
+	"SunD1":  {Description: "Sunshine duration during the last hour", Unit: "s"},
+	"SunD3":  {Description: "Sunshine duration during the last 3 hours", Unit: "s"},
+	"RSunD":  {Description: "Relative sunshine duration within the last 24 hours", Unit: "%"},
+	"Rad1h":  {Description: "Global irradiance within the last hour", Unit: "kJ/m²"},
+	"PEvap":  {Description: "Potential evapotranspiration within the last 24 hours", Unit: "kg/m²"},
+	"SunD":   {Description: "Yesterdays total sunshine duration", Unit: "s"},
+	"wwM":    {Description: "Probability for fog within the last hour", Unit: "%"},
+	"E_TTT":  {Description: "Absolute error temperature 2m above surface", Unit: "K"},
+	"E_Td":   {Description: "Absolute error dewpoint 2m above surface", Unit: "K"},
+	"E_FF":   {Description: "Absolute error wind speed 10m above surface", Unit: "m/s"},
+	"E_DD":   {Description: "Absolute error wind direction", Unit: "°"},
+	"E_PPP":  {Description: "Absolute error surface pressure", Unit: "Pa"},
+	"RRad1":  {Description: "Global irradiance within the last hour relative to a clear sky", Unit: "%"},
+	"Rh00":   {Description: "Probability of precipitation > 0.0 mm during the last 12 hours", Unit: "%"},
+	"RRL1c":  {Description: "Total liquid precipitation during the last hour consistent with significant weather", Unit: "kg/m²"},
+	"W1W2":   {Description: "Past weather during the last 6 hours", Unit: ""},
+	"WPc11":  {Description: "Optional significant weather (highest priority) during the last hour", Unit: ""},
+	"Rd10":   {Description: "Probability of precipitation > 1.0 mm during the last 24 hours", Unit: "%"},
+	"Rd50":   {Description: "Probability of precipitation > 5.0 mm during the last 24 hours", Unit: "%"},
+	"RR6c":   {Description: "Total precipitation during the last 6 hours consistent with significant weather", Unit: "kg/m²"},
+	"RRdc":   {Description: "Total precipitation during the last 24 hours consistent with significant weather", Unit: "kg/m²"},
+	"SunD24": {Description: "Sunshine duration during the last 24 hours", Unit: "s"},
+}
+
+// MosmixForecast contains the forecast of a single station taken from a
+// MOSMIX forecast.
+// The values of each element are kept as the whitespace separated list used
+// in the KML documents, which keeps the forecast compact while it is cached.
+type MosmixForecast struct {
+	StationID string      `json:"station"`
+	TimeSteps []time.Time `json:"timeSteps"`
+	Elements  []string    `json:"elements"`
+	Values    []string    `json:"values"`
+}
+
+// mosmixPlacemark is a station contained in a MOSMIX forecast.
+type mosmixPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Coordinates string `xml:"Point>coordinates"`
+	Forecasts   []struct {
+		Element string `xml:"elementName,attr"`
+		Values  string `xml:"value"`
+	} `xml:"ExtendedData>Forecast"`
+}
+
+// location parses the coordinates of the placemark, which are given as
+// `<longitude>,<latitude>,<height>`.
+func (p mosmixPlacemark) location() (*geom.Point, error) {
+	parts := strings.Split(strings.TrimSpace(p.Coordinates), ",")
+	if len(parts) != 3 { //nolint:mnd
+		return nil, errors.New("malformed placemark coordinates")
+	}
+
+	coordinates := make([]float64, len(parts))
+	for idx, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		coordinates[idx] = coordinate
+	}
+
+	location := geom.NewPointFlat(geom.XYZ, coordinates)
+	location.SetSRID(coordinateSRID)
+	return location, nil
+}
+
+// openMosmixDocument opens the KML document contained in a KMZ archive.
+func openMosmixDocument(path string) (document io.ReadCloser, archive *zip.ReadCloser, err error) {
+	archive, err = zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range archive.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".kml") {
+			continue
+		}
+
+		document, err := file.Open()
+		if err != nil {
+			_ = archive.Close()
+			return nil, nil, err
+		}
+		return document, archive, nil
+	}
+
+	_ = archive.Close()
+	return nil, nil, errMosmixDocumentMissing
+}
+
+// walkMosmixDocument streams the placemarks of a MOSMIX KML document.
+// The forecast time steps are declared in front of the placemarks and are
+// passed to the visitor together with each placemark.
+// Returning false from the visitor stops reading the document.
+func walkMosmixDocument(r io.Reader, visit func(timeSteps []time.Time, placemark mosmixPlacemark) (bool, error)) error { //nolint:lll
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = xmlCharsetReader
+	var timeSteps []time.Time
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, isStart := token.(xml.StartElement)
+		if !isStart {
+			continue
+		}
+
+		switch start.Name.Local {
+		case mosmixElement_TimeStep:
+			var timeStep string
+			if err := decoder.DecodeElement(&timeStep, &start); err != nil {
+				return err
+			}
+			ts, err := time.Parse(time.RFC3339, strings.TrimSpace(timeStep))
+			if err != nil {
+				return err
+			}
+			timeSteps = append(timeSteps, ts)
+		case mosmixElement_Placemark:
+			var placemark mosmixPlacemark
+			if err := decoder.DecodeElement(&placemark, &start); err != nil {
+				return err
+			}
+			next, err := visit(timeSteps, placemark)
+			if err != nil || !next {
+				return err
+			}
+		}
+	}
+}
+
+// ReadMosmixStations reads the stations contained in the MOSMIX forecast in
+// the KMZ archive at the path.
+// The availability of the stations is set to the range of the forecast.
+func ReadMosmixStations(path string) (stations []v2.Station, forecastRange v2.DateTimeRange, err error) {
+	document, archive, err := openMosmixDocument(path)
+	if err != nil {
+		return nil, forecastRange, err
+	}
+	defer archive.Close()
+	defer document.Close()
+
+	err = walkMosmixDocument(document, func(timeSteps []time.Time, placemark mosmixPlacemark) (bool, error) {
+		if len(timeSteps) > 0 {
+			forecastRange = v2.DateTimeRange{Start: timeSteps[0], End: timeSteps[len(timeSteps)-1]}
+		}
+
+		location, err := placemark.location()
+		if err != nil {
+			return false, err
+		}
+
+		stations = append(stations, v2.Station{
+			ID:       strings.TrimSpace(placemark.Name),
+			Name:     strings.TrimSpace(placemark.Description),
+			Location: location,
+		})
+		return true, nil
+	})
+	if err != nil {
+		return nil, forecastRange, err
+	}
+
+	return stations, forecastRange, nil
+}
+
+// ReadMosmixIssueTime reads the time the MOSMIX forecast in the KMZ archive
+// at the path has been issued at.
+// The issue time is declared in front of the placemarks, so only the head of
+// the document is read.
+func ReadMosmixIssueTime(path string) (time.Time, error) {
+	document, archive, err := openMosmixDocument(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer archive.Close()
+	defer document.Close()
+
+	decoder := xml.NewDecoder(document)
+	decoder.CharsetReader = xmlCharsetReader
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return time.Time{}, errMosmixIssueTimeMissing
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		start, isStart := token.(xml.StartElement)
+		if !isStart {
+			continue
+		}
+
+		switch start.Name.Local {
+		case mosmixElement_IssueTime:
+			var issueTime string
+			if err := decoder.DecodeElement(&issueTime, &start); err != nil {
+				return time.Time{}, err
+			}
+			return time.Parse(time.RFC3339, strings.TrimSpace(issueTime))
+		case mosmixElement_Placemark:
+			return time.Time{}, errMosmixIssueTimeMissing
+		}
+	}
+}
+
+// ReadMosmixForecasts reads the forecasts of all stations from the MOSMIX
+// forecast in the KMZ archive at the path and passes them to the visitor one
+// after another.
+func ReadMosmixForecasts(path string, visit func(forecast MosmixForecast) error) error {
+	document, archive, err := openMosmixDocument(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	defer document.Close()
+
+	return walkMosmixDocument(document, func(timeSteps []time.Time, placemark mosmixPlacemark) (bool, error) {
+		return true, visit(placemark.forecast(timeSteps))
+	})
+}
+
+// OpenMosmixForecast reads the forecast of the station from the MOSMIX
+// forecast in the KMZ archive at the path.
+// If the forecast does not contain the station, the returned forecast contains
+// no labels and datapoints.
+// If labels are supplied, only the elements with these labels are read.
+func OpenMosmixForecast(path, stationID string, labels []string) (*Archive, error) {
+	document, archive, err := openMosmixDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	defer document.Close()
+
+	forecast := MosmixForecast{StationID: stationID}
+	err = walkMosmixDocument(document, func(timeSteps []time.Time, placemark mosmixPlacemark) (bool, error) {
+		if strings.TrimSpace(placemark.Name) != stationID {
+			return true, nil
+		}
+		forecast = placemark.forecast(timeSteps)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return forecast.Archive(labels), nil
+}
+
+// forecast converts the placemark into the forecast of its station.
+func (p mosmixPlacemark) forecast(timeSteps []time.Time) MosmixForecast {
+	forecast := MosmixForecast{
+		StationID: strings.TrimSpace(p.Name),
+		TimeSteps: timeSteps,
+		Elements:  make([]string, len(p.Forecasts)),
+		Values:    make([]string, len(p.Forecasts)),
+	}
+	for idx, f := range p.Forecasts {
+		forecast.Elements[idx] = f.Element
+		forecast.Values[idx] = strings.TrimSpace(f.Values)
+	}
+	return forecast
+}
+
+// Archive converts the forecast into an archive containing a datapoint for
+// every element and time step.
+// If labels are supplied, only the elements with these labels are contained
+// in the archive.
+func (f MosmixForecast) Archive(labels []string) *Archive {
+	forecast := &Archive{selectedLabels: labels}
+
+	var validFrom, validUntil time.Time
+	if len(f.TimeSteps) > 0 {
+		validFrom, validUntil = f.TimeSteps[0], f.TimeSteps[len(f.TimeSteps)-1]
+	}
+
+	values := make([][]string, 0, len(f.Elements))
+	for idx, label := range f.Elements {
+		if !forecast.selected(label) {
+			continue
+		}
+
+		element := MosmixElements[label]
+		forecast.Labels = append(forecast.Labels, label)
+		forecast.Metadata = append(forecast.Metadata, v2.FieldMetadata{
+			Name:        label,
+			Description: element.Description,
+			Unit:        element.Unit,
+			ValidFrom:   validFrom,
+			ValidUntil:  validUntil,
+		})
+		if idx < len(f.Values) {
+			values = append(values, strings.Fields(f.Values[idx]))
+		} else {
+			values = append(values, nil)
+		}
+	}
+
+	for stepIdx, ts := range f.TimeSteps {
+		for elementIdx, label := range forecast.Labels {
+			dp := v2.Datapoint{
+				Label:     label,
+				Timestamp: ts,
+			}
+
+			if unit := MosmixElements[label].Unit; unit != "" {
+				dp.Unit = &unit
+			}
+
+			if stepIdx < len(values[elementIdx]) && values[elementIdx][stepIdx] != mosmixMissingValue {
+				value := values[elementIdx][stepIdx]
+				if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+					dp.Value = floatValue
+				} else {
+					dp.Value = value
+				}
+			}
+
+			forecast.bufferedDatapoints = append(forecast.bufferedDatapoints, dp)
+		}
+	}
+
+	return forecast
+}
@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// sampleMosmixDocument is a MOSMIX forecast with three time steps for two
+// stations, trimmed down from a MOSMIX_S run.
+//
+//nolint:lll
+const sampleMosmixDocument = `<?xml version="1.0" encoding="ISO-8859-1" standalone="no"?>
+<kml:kml xmlns:dwd="https://opendata.dwd.de/weather/lib/pointforecast_dwd_extension_V1_0.xsd" xmlns:kml="http://www.opengis.net/kml/2.2">
+    <kml:Document>
+        <kml:ExtendedData>
+            <dwd:ProductDefinition>
+                <dwd:Issuer>Deutscher Wetterdienst</dwd:Issuer>
+                <dwd:ProductID>MOSMIX</dwd:ProductID>
+                <dwd:GeneratingProcess>DWD MOSMIX hourly, Version 1.0</dwd:GeneratingProcess>
+                <dwd:IssueTime>2024-10-18T09:00:00.000Z</dwd:IssueTime>
+                <dwd:ForecastTimeSteps>
+                    <dwd:TimeStep>2024-10-18T10:00:00.000Z</dwd:TimeStep>
+                    <dwd:TimeStep>2024-10-18T11:00:00.000Z</dwd:TimeStep>
+                    <dwd:TimeStep>2024-10-18T12:00:00.000Z</dwd:TimeStep>
+                </dwd:ForecastTimeSteps>
+            </dwd:ProductDefinition>
+        </kml:ExtendedData>
+        <kml:Placemark>
+            <kml:name>10015</kml:name>
+            <kml:description>HELGOLAND</kml:description>
+            <kml:ExtendedData>
+                <dwd:Forecast dwd:elementName="TTT">
+                    <dwd:value>     286.45     286.35     286.25</dwd:value>
+                </dwd:Forecast>
+                <dwd:Forecast dwd:elementName="FF">
+                    <dwd:value>       9.77       9.26          -</dwd:value>
+                </dwd:Forecast>
+            </kml:ExtendedData>
+            <kml:Point>
+                <kml:coordinates>7.9,54.18,4.0</kml:coordinates>
+            </kml:Point>
+        </kml:Placemark>
+        <kml:Placemark>
+            <kml:name>10865</kml:name>
+            <kml:description>MÜNCHEN-STADT</kml:description>
+            <kml:ExtendedData>
+                <dwd:Forecast dwd:elementName="TTT">
+                    <dwd:value>     283.15     284.05     285.15</dwd:value>
+                </dwd:Forecast>
+                <dwd:Forecast dwd:elementName="FF">
+                    <dwd:value>       1.54       2.06       2.57</dwd:value>
+                </dwd:Forecast>
+                <dwd:Forecast dwd:elementName="XYZ">
+                    <dwd:value>          1          2          3</dwd:value>
+                </dwd:Forecast>
+            </kml:ExtendedData>
+            <kml:Point>
+                <kml:coordinates>11.55,48.16,515.0</kml:coordinates>
+            </kml:Point>
+        </kml:Placemark>
+    </kml:Document>
+</kml:kml>
+`
+
+// writeMosmixArchive writes a KMZ archive containing the document encoded in
+// ISO-8859-1 to a temporary directory and returns its path.
+func writeMosmixArchive(t *testing.T, document string) string {
+	t.Helper()
+
+	encoded, err := charmap.ISO8859_1.NewEncoder().String(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "MOSMIX_S_LATEST_240.kmz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	kml, err := writer.Create("MOSMIX_S_2024101809_240.kml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kml.Write([]byte(encoded)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadMosmixStations(t *testing.T) {
+	stations, forecastRange, err := ReadMosmixStations(writeMosmixArchive(t, sampleMosmixDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRange := struct{ start, end time.Time }{
+		start: time.Date(2024, 10, 18, 10, 0, 0, 0, time.UTC),
+		end:   time.Date(2024, 10, 18, 12, 0, 0, 0, time.UTC),
+	}
+	if !forecastRange.Start.Equal(expectedRange.start) || !forecastRange.End.Equal(expectedRange.end) {
+		t.Errorf("expected range %v, got %v", expectedRange, forecastRange)
+	}
+
+	expected := []struct {
+		id, name            string
+		longitude, latitude float64
+		height              float64
+	}{
+		{id: "10015", name: "HELGOLAND", longitude: 7.9, latitude: 54.18, height: 4},
+		{id: "10865", name: "MÜNCHEN-STADT", longitude: 11.55, latitude: 48.16, height: 515},
+	}
+	if len(stations) != len(expected) {
+		t.Fatalf("expected %d stations, got %v", len(expected), stations)
+	}
+	for idx, e := range expected {
+		station := stations[idx]
+		if station.ID != e.id || station.Name != e.name {
+			t.Errorf("expected station %s (%s), got %s (%s)", e.id, e.name, station.ID, station.Name)
+		}
+		if station.Location.X() != e.longitude || station.Location.Y() != e.latitude || station.Location.Z() != e.height {
+			t.Errorf("%s: unexpected location %v", e.id, station.Location.Coords())
+		}
+	}
+}
+
+func TestOpenMosmixForecast(t *testing.T) {
+	path := writeMosmixArchive(t, sampleMosmixDocument)
+
+	tests := []struct {
+		name    string
+		station string
+		labels  []string
+		values  map[string][]any
+	}{
+		{
+			name:    "all elements",
+			station: "10865",
+			values: map[string][]any{
+				"TTT": {283.15, 284.05, 285.15},
+				"FF":  {1.54, 2.06, 2.57},
+				"XYZ": {1.0, 2.0, 3.0},
+			},
+		},
+		{
+			name:    "missing values",
+			station: "10015",
+			labels:  []string{"FF"},
+			values: map[string][]any{
+				"FF": {9.77, 9.26, nil},
+			},
+		},
+		{
+			name:    "unknown station",
+			station: "99999",
+			values:  map[string][]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast, err := OpenMosmixForecast(path, tt.station, tt.labels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer forecast.Close()
+
+			checkMosmixForecast(t, forecast, tt.values)
+		})
+	}
+}
+
+func TestReadMosmixIssueTime(t *testing.T) {
+	issueTime, err := ReadMosmixIssueTime(writeMosmixArchive(t, sampleMosmixDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2024, 10, 18, 9, 0, 0, 0, time.UTC); !issueTime.Equal(expected) {
+		t.Errorf("expected issue time %s, got %s", expected, issueTime)
+	}
+
+	document := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<kml:kml xmlns:kml="http://www.opengis.net/kml/2.2"><kml:Document><kml:Placemark><kml:name>10015</kml:name></kml:Placemark></kml:Document></kml:kml>` //nolint:lll
+	if _, err := ReadMosmixIssueTime(writeMosmixArchive(t, document)); err == nil {
+		t.Error("expected an error for a document without issue time")
+	}
+}
+
+func TestReadMosmixForecasts(t *testing.T) {
+	var forecasts []MosmixForecast
+	err := ReadMosmixForecasts(writeMosmixArchive(t, sampleMosmixDocument), func(forecast MosmixForecast) error {
+		forecasts = append(forecasts, forecast)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(forecasts) != 2 || forecasts[0].StationID != "10015" || forecasts[1].StationID != "10865" {
+		t.Fatalf("unexpected forecasts %+v", forecasts)
+	}
+	if len(forecasts[0].TimeSteps) != 3 {
+		t.Errorf("expected 3 time steps, got %v", forecasts[0].TimeSteps)
+	}
+
+	// the forecasts read from the whole run match the forecast of the station
+	checkMosmixForecast(t, forecasts[0].Archive([]string{"FF"}), map[string][]any{
+		"FF": {9.77, 9.26, nil},
+	})
+}
+
+// checkMosmixForecast checks the values of the elements in the forecast, which
+// are expected for every time step in the sample document.
+func checkMosmixForecast(t *testing.T, forecast *Archive, values map[string][]any) {
+	t.Helper()
+
+	if len(forecast.Labels) != len(values) || len(forecast.Metadata) != len(values) {
+		t.Fatalf("expected %d elements, got labels %v and metadata %v", len(values), forecast.Labels, forecast.Metadata)
+	}
+
+	steps := make(map[string]int)
+	for dp, err := range forecast.Datapoints() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, found := values[dp.Label]
+		if !found {
+			t.Fatalf("unexpected element %s", dp.Label)
+		}
+		step := steps[dp.Label]
+		steps[dp.Label]++
+		if step >= len(expected) {
+			t.Fatalf("%s: unexpected time step %s", dp.Label, dp.Timestamp)
+		}
+
+		if expectedTimestamp := time.Date(2024, 10, 18, 10+step, 0, 0, 0, time.UTC); !dp.Timestamp.Equal(expectedTimestamp) {
+			t.Errorf("%s: expected time step %s, got %s", dp.Label, expectedTimestamp, dp.Timestamp)
+		}
+		if dp.Value != expected[step] {
+			t.Errorf("%s at %s: expected %v, got %v", dp.Label, dp.Timestamp, expected[step], dp.Value)
+		}
+		if element, known := MosmixElements[dp.Label]; known && (dp.Unit == nil || *dp.Unit != element.Unit) {
+			t.Errorf("%s: expected unit %s, got %v", dp.Label, element.Unit, dp.Unit)
+		}
+	}
+
+	for label, expected := range values {
+		if steps[label] != len(expected) {
+			t.Errorf("%s: expected %d time steps, got %d", label, len(expected), steps[label])
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	multiAnnualFieldName_StationID       = "Stations_ID"
+	multiAnnualFieldName_ReferencePeriod = "Bezugszeitraum"
+)
+
+// multiAnnualColumns contains the labels of the columns in the multi-annual
+// mean files which contain the mean values and the description of the period
+// they have been calculated for.
+// The DWD terminates the abbreviated month names with a dot, which is removed
+// from the labels.
+var multiAnnualColumns = map[string]string{
+	"Jan":  "January",
+	"Feb":  "February",
+	"Mrz":  "March",
+	"Apr":  "April",
+	"Mai":  "May",
+	"Jun":  "June",
+	"Jul":  "July",
+	"Aug":  "August",
+	"Sep":  "September",
+	"Okt":  "October",
+	"Nov":  "November",
+	"Dez":  "December",
+	"Jahr": "Year",
+}
+
+var errMalformedReferencePeriod = errors.New("malformed reference period")
+
+// OpenMultiAnnualMeans reads the multi-annual means of the station from the
+// file at the path.
+// Other than the archives containing the observations, the multi-annual mean
+// files are plain semicolon separated tables containing a row per station and
+// reference period with a column for the mean of each month and the year.
+// The description and unit of the parameter are attached to the metadata and
+// datapoints since the files do not contain them.
+// If labels are supplied, only the means with these labels are read from the
+// file.
+func OpenMultiAnnualMeans(path, stationID, description, unit string, labels []string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	archive := &Archive{selectedLabels: labels}
+
+	reader := csv.NewReader(transform.NewReader(f, charmap.Windows1252.NewDecoder().Transformer))
+	reader.TrimLeadingSpace = true
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	header = slices.Clone(header)
+	for idx := range header {
+		header[idx] = strings.TrimSuffix(strings.TrimSpace(header[idx]), ".")
+	}
+
+	stationIdx := slices.Index(header, multiAnnualFieldName_StationID)
+	periodIdx := slices.Index(header, multiAnnualFieldName_ReferencePeriod)
+	if stationIdx == -1 || periodIdx == -1 {
+		return nil, errors.New("unsupported multi-annual mean file")
+	}
+
+	var valueIdxs []int
+	for idx, rowHead := range header {
+		if _, isMean := multiAnnualColumns[rowHead]; isMean && archive.selected(rowHead) {
+			valueIdxs = append(valueIdxs, idx)
+			archive.Labels = append(archive.Labels, rowHead)
+		}
+	}
+
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) <= max(stationIdx, periodIdx) || !sameStation(line[stationIdx], stationID) {
+			continue
+		}
+
+		period, err := parseReferencePeriod(line[periodIdx])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, idx := range valueIdxs {
+			label := header[idx]
+			archive.Metadata = append(archive.Metadata, v2.FieldMetadata{
+				Name:        label,
+				Description: description + " (" + multiAnnualColumns[label] + ")",
+				Unit:        unit,
+				ValidFrom:   period.Start,
+				ValidUntil:  period.End,
+			})
+
+			dp := v2.Datapoint{
+				Label:           label,
+				Timestamp:       period.Start,
+				Unit:            &unit,
+				ReferencePeriod: &period,
+			}
+
+			if idx < len(line) {
+				val := strings.TrimSpace(line[idx])
+				if floatValue, err := strconv.ParseFloat(val, 64); err == nil {
+					dp.Value = floatValue
+				} else if val != "" {
+					dp.Value = val
+				}
+			}
+
+			archive.bufferedDatapoints = append(archive.bufferedDatapoints, dp)
+		}
+	}
+
+	slices.SortStableFunc(archive.bufferedDatapoints, func(a, b v2.Datapoint) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	return archive, nil
+}
+
+// sameStation compares the station ids while ignoring the zero-padding, which
+// is missing in the multi-annual mean files.
+func sameStation(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	aID, errA := strconv.Atoi(a)
+	bID, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return aID == bID
+}
+
+// parseReferencePeriod parses a reference period in the form `1991-2020`.
+// The period covers the start and end years completely.
+func parseReferencePeriod(s string) (v2.DateTimeRange, error) {
+	startString, endString, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		return v2.DateTimeRange{}, errMalformedReferencePeriod
+	}
+
+	startYear, err := strconv.Atoi(strings.TrimSpace(startString))
+	if err != nil {
+		return v2.DateTimeRange{}, errMalformedReferencePeriod
+	}
+
+	endYear, err := strconv.Atoi(strings.TrimSpace(endString))
+	if err != nil {
+		return v2.DateTimeRange{}, errMalformedReferencePeriod
+	}
+
+	return v2.DateTimeRange{
+		Start: time.Date(startYear, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(endYear, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+// ReadMultiAnnualStations reads the ids of the stations contained in a
+// multi-annual mean file together with the reference periods available for
+// them.
+// The ids are zero-padded to match the ids used in the station lists.
+func ReadMultiAnnualStations(r io.Reader) (map[string][]v2.DateTimeRange, error) {
+	reader := csv.NewReader(transform.NewReader(r, charmap.Windows1252.NewDecoder().Transformer))
+	reader.TrimLeadingSpace = true
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	stationIdx := slices.IndexFunc(header, func(s string) bool {
+		return strings.TrimSpace(s) == multiAnnualFieldName_StationID
+	})
+	periodIdx := slices.IndexFunc(header, func(s string) bool {
+		return strings.TrimSpace(s) == multiAnnualFieldName_ReferencePeriod
+	})
+	if stationIdx == -1 || periodIdx == -1 {
+		return nil, errors.New("unsupported multi-annual mean file")
+	}
+
+	stations := make(map[string][]v2.DateTimeRange)
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return stations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) <= max(stationIdx, periodIdx) {
+			continue
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(line[stationIdx]))
+		if err != nil {
+			continue
+		}
+
+		period, err := parseReferencePeriod(line[periodIdx])
+		if err != nil {
+			return nil, err
+		}
+
+		stationID := padStationID(id)
+		stations[stationID] = append(stations[stationID], period)
+	}
+}
+
+// padStationID formats the station id with the zero-padding used by the DWD.
+func padStationID(id int) string {
+	s := strconv.Itoa(id)
+	if len(s) >= 5 { //nolint:mnd
+		return s
+	}
+	return strings.Repeat("0", 5-len(s)) + s //nolint:mnd
+}
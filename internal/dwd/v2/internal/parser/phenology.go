@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	phenologyFieldName_StationID     = "Stations_id"
+	phenologyFieldName_StationName   = "Stationsname"
+	phenologyFieldName_Latitude      = "geograph.Breite"
+	phenologyFieldName_Longitude     = "geograph.Laenge"
+	phenologyFieldName_Height        = "Stationshoehe"
+	phenologyFieldName_ReferenceYear = "Referenzjahr"
+	phenologyFieldName_PlantID       = "Objekt_id"
+	phenologyFieldName_PhaseID       = "Phase_id"
+	phenologyFieldName_Date          = "Eintrittsdatum"
+	phenologyFieldName_DayOfYear     = "Jultag"
+
+	phenologyFieldName_Plant        = "Objekt"
+	phenologyFieldName_PlantEnglish = "Objekt_englisch"
+	phenologyFieldName_Phase        = "Phase"
+	phenologyFieldName_PhaseEnglish = "Phase_englisch"
+
+	// phenologyUnit is the unit of the phenological datapoints, whose values
+	// contain the day of the year the phase has been observed on.
+	phenologyUnit = "day of year"
+)
+
+var errUnsupportedPhenologyFile = errors.New("unsupported phenology file")
+
+// ReadPhenologyStations reads the stations contained in the station list of
+// the phenology database.
+// The ids of the stations are zero-padded to match the ids of the other
+// databases.
+func ReadPhenologyStations(r io.Reader) ([]v2.Station, error) {
+	table, err := openSemicolonTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.hasColumns(phenologyFieldName_StationID, phenologyFieldName_Latitude, phenologyFieldName_Longitude) {
+		return nil, errUnsupportedPhenologyFile
+	}
+
+	var stations []v2.Station
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := strconv.Atoi(row(phenologyFieldName_StationID))
+		if err != nil {
+			continue
+		}
+
+		latitude, err := strconv.ParseFloat(row(phenologyFieldName_Latitude), 64)
+		if err != nil {
+			return nil, err
+		}
+		longitude, err := strconv.ParseFloat(row(phenologyFieldName_Longitude), 64)
+		if err != nil {
+			return nil, err
+		}
+		height, _ := strconv.ParseFloat(row(phenologyFieldName_Height), 64)
+
+		location := geom.NewPointFlat(geom.XYZ, []float64{longitude, latitude, height})
+		location.SetSRID(coordinateSRID)
+
+		stations = append(stations, v2.Station{
+			ID:       padStationID(id),
+			Name:     row(phenologyFieldName_StationName),
+			Height:   height,
+			Location: location,
+		})
+	}
+
+	return stations, nil
+}
+
+// ReadPhenologyPlants reads the names of the plants from the plant table.
+// The names contain the German and the English name of the plant.
+func ReadPhenologyPlants(r io.Reader) (map[int]string, error) {
+	return readPhenologyCodes(r, phenologyFieldName_PlantID, phenologyFieldName_Plant, phenologyFieldName_PlantEnglish)
+}
+
+// ReadPhenologyPhases reads the names of the phases from the phase table.
+// The names contain the German and the English name of the phase.
+func ReadPhenologyPhases(r io.Reader) (map[int]string, error) {
+	return readPhenologyCodes(r, phenologyFieldName_PhaseID, phenologyFieldName_Phase, phenologyFieldName_PhaseEnglish)
+}
+
+// readPhenologyCodes reads a code table mapping the codes to their German
+// names, which are followed by their English names if available.
+func readPhenologyCodes(r io.Reader, idColumn, nameColumn, englishColumn string) (map[int]string, error) {
+	table, err := openSemicolonTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.hasColumns(idColumn, nameColumn) {
+		return nil, errUnsupportedPhenologyFile
+	}
+
+	codes := make(map[int]string)
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := strconv.Atoi(row(idColumn))
+		if err != nil {
+			continue
+		}
+
+		name := row(nameColumn)
+		if english := row(englishColumn); english != "" && english != name {
+			name += " (" + english + ")"
+		}
+		codes[id] = name
+	}
+	return codes, nil
+}
+
+// ReadPhenologyAvailability reads the dates of the first and last observation
+// of each station contained in the report file.
+// The ids of the stations are zero-padded to match the station list.
+func ReadPhenologyAvailability(r io.Reader) (map[string]v2.DateTimeRange, error) {
+	table, err := openSemicolonTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.hasColumns(phenologyFieldName_StationID, phenologyFieldName_Date) {
+		return nil, errUnsupportedPhenologyFile
+	}
+
+	availability := make(map[string]v2.DateTimeRange)
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := time.Parse(df_DayOnly, row(phenologyFieldName_Date))
+		if err != nil {
+			continue
+		}
+
+		stationID, err := strconv.Atoi(row(phenologyFieldName_StationID))
+		if err != nil {
+			continue
+		}
+
+		id := padStationID(stationID)
+		available, known := availability[id]
+		if !known || date.Before(available.Start) {
+			available.Start = date
+		}
+		if !known || date.After(available.End) {
+			available.End = date
+		}
+		availability[id] = available
+	}
+	return availability, nil
+}
+
+// OpenPhenologyObservations reads the observations of the station from the
+// report file at the path.
+// The report files contain the observations of all stations for a single
+// plant with a row per station, phase and year.
+// Each combination of plant and phase is returned as a separate parameter
+// labelled `<plant>_<phase>` using the codes of the plant and phase, which
+// are described using the names from the code tables.
+// If labels are supplied, only the parameters with these labels are read.
+func OpenPhenologyObservations(path, stationID string, plants, phases map[int]string, labels []string) (*Archive, error) { //nolint:lll
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table, err := openSemicolonTable(f)
+	if err != nil {
+		return nil, err
+	}
+	if !table.hasColumns(phenologyFieldName_StationID, phenologyFieldName_PlantID, phenologyFieldName_PhaseID,
+		phenologyFieldName_Date) {
+		return nil, errUnsupportedPhenologyFile
+	}
+
+	archive := &Archive{selectedLabels: labels}
+	metadata := make(map[string]*v2.FieldMetadata)
+
+	for row, err := range table.rows {
+		if err != nil {
+			return nil, err
+		}
+
+		if !sameStation(row(phenologyFieldName_StationID), stationID) {
+			continue
+		}
+
+		plantID, err := strconv.Atoi(row(phenologyFieldName_PlantID))
+		if err != nil {
+			return nil, err
+		}
+		phaseID, err := strconv.Atoi(row(phenologyFieldName_PhaseID))
+		if err != nil {
+			return nil, err
+		}
+
+		label := strconv.Itoa(plantID) + "_" + strconv.Itoa(phaseID)
+		if !archive.selected(label) {
+			continue
+		}
+
+		date, err := time.Parse(df_DayOnly, row(phenologyFieldName_Date))
+		if err != nil {
+			return nil, err
+		}
+
+		referenceYear, err := strconv.Atoi(row(phenologyFieldName_ReferenceYear))
+		if err != nil {
+			referenceYear = date.Year()
+		}
+
+		dayOfYear, err := strconv.Atoi(row(phenologyFieldName_DayOfYear))
+		if err != nil {
+			dayOfYear = date.YearDay()
+		}
+
+		field, known := metadata[label]
+		if !known {
+			field = &v2.FieldMetadata{
+				Name:        label,
+				Description: plants[plantID] + ": " + phases[phaseID],
+				Unit:        phenologyUnit,
+				ValidFrom:   date,
+				ValidUntil:  date,
+			}
+			metadata[label] = field
+			archive.Labels = append(archive.Labels, label)
+		}
+		if date.Before(field.ValidFrom) {
+			field.ValidFrom = date
+		}
+		if date.After(field.ValidUntil) {
+			field.ValidUntil = date
+		}
+
+		unit := phenologyUnit
+		archive.bufferedDatapoints = append(archive.bufferedDatapoints, v2.Datapoint{
+			Label:     label,
+			Timestamp: date,
+			Value:     float64(dayOfYear),
+			Unit:      &unit,
+			Phenology: &v2.PhenologyObservation{
+				PlantID:       plantID,
+				Plant:         plants[plantID],
+				PhaseID:       phaseID,
+				Phase:         phases[phaseID],
+				ReferenceYear: referenceYear,
+			},
+		})
+	}
+
+	for _, label := range archive.Labels {
+		archive.Metadata = append(archive.Metadata, *metadata[label])
+	}
+
+	slices.SortStableFunc(archive.bufferedDatapoints, func(a, b v2.Datapoint) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	return archive, nil
+}
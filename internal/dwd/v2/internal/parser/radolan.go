@@ -0,0 +1,366 @@
+package parser
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	// radolanHeaderEnd terminates the ASCII header of a RADOLAN composite.
+	radolanHeaderEnd = 0x03
+
+	// radolanHeaderMinimumLength is the length of the fixed part of the header
+	// containing the product and the timestamp of the composite.
+	radolanHeaderMinimumLength = 17
+
+	df_RadolanHeader = "0215040106"
+)
+
+// The pixels of the RADOLAN products are stored as 16 bit little endian
+// integers, which contain the value in the lower 12 bits and flags in the
+// upper 4 bits.
+const (
+	radolanMask_Value     = 0x0FFF
+	radolanFlag_Secondary = 0x1000
+	radolanFlag_Missing   = 0x2000
+	radolanFlag_Negative  = 0x4000
+	radolanFlag_Clutter   = 0x8000
+)
+
+// The RADOLAN grids use a polar stereographic projection of a sphere which is
+// true at 60° N with 10° E as central meridian.
+// The cells of the grids have a size of 1 km × 1 km.
+const (
+	radolanEarthRadius      = 6370.04
+	radolanStandardParallel = 60.0
+	radolanCentralMeridian  = 10.0
+)
+
+// radolanGridOrigins contains the projected coordinates of the lower left
+// corner of the supported RADOLAN grids mapped to their rows and columns.
+var radolanGridOrigins = map[[2]int][2]float64{
+	{900, 900}:   {-523.4622, -4658.645},
+	{1100, 900}:  {-443.4622, -4758.645},
+	{1500, 1400}: {-673.4622, -5008.645},
+}
+
+var (
+	errMalformedRadolanHeader = errors.New("malformed radolan header")
+	errUnsupportedRadolanGrid = errors.New("unsupported radolan grid")
+)
+
+var (
+	radolanPrecisionPattern = regexp.MustCompile(`PR\s*E([+-]?\d+)`)
+	radolanIntervalPattern  = regexp.MustCompile(`INT\s*(\d+)`)
+	radolanGridPattern      = regexp.MustCompile(`GP\s*(\d+)\s*x\s*(\d+)`)
+)
+
+// RadolanProducts contains the descriptions of the supported RADOLAN products
+// mapped to their product codes, which are used as labels of the datapoints.
+var RadolanProducts = map[string]v2.FieldMetadata{
+	"RW": {
+		Name:        "RW",
+		Description: "Hourly precipitation height of the RADOLAN RW composite, adjusted to the rain gauges",
+		Unit:        "mm",
+	},
+	"SF": {
+		Name:        "SF",
+		Description: "Precipitation height of the last 24 hours of the RADOLAN SF composite, adjusted to the rain gauges", //nolint:lll
+		Unit:        "mm",
+	},
+}
+
+// RadolanComposite is a single composite of a RADOLAN product.
+type RadolanComposite struct {
+	// Product contains the code of the product (e.g. RW, SF).
+	Product string
+
+	// Timestamp contains the end of the interval covered by the composite.
+	Timestamp time.Time
+
+	// Interval contains the length of the interval covered by the composite.
+	Interval time.Duration
+
+	// Rows and Columns contain the size of the grid.
+	Rows, Columns int
+
+	// Precision contains the factor the raw values are multiplied with.
+	Precision float64
+
+	pixels []uint16
+}
+
+// Value returns the value of the grid cell with the index.
+// The cells are ordered row by row starting in the lower left (south-western)
+// corner of the grid.
+// Cells without data or marked as clutter are reported as invalid.
+func (c *RadolanComposite) Value(idx int) (value float64, valid bool) {
+	if idx < 0 || idx >= len(c.pixels) {
+		return 0, false
+	}
+
+	pixel := c.pixels[idx]
+	if pixel&(radolanFlag_Missing|radolanFlag_Clutter) != 0 {
+		return 0, false
+	}
+
+	value = scaleValue(float64(pixel&radolanMask_Value), c.Precision)
+	if pixel&radolanFlag_Negative != 0 {
+		value = -value
+	}
+	return value, true
+}
+
+// Project converts a coordinate into the fractional column and row of the
+// grid of the composite.
+func (c *RadolanComposite) Project(longitude, latitude float64) (column, row float64) {
+	origin := radolanGridOrigins[[2]int{c.Rows, c.Columns}]
+
+	phi := latitude * math.Pi / 180                                //nolint:mnd
+	lambda := (longitude - radolanCentralMeridian) * math.Pi / 180 //nolint:mnd
+	phi0 := radolanStandardParallel * math.Pi / 180                //nolint:mnd
+	scale := (1 + math.Sin(phi0)) / (1 + math.Sin(phi))
+
+	x := radolanEarthRadius * scale * math.Cos(phi) * math.Sin(lambda)
+	y := -radolanEarthRadius * scale * math.Cos(phi) * math.Cos(lambda)
+	return x - origin[0], y - origin[1]
+}
+
+// ReadRadolanComposite reads a single uncompressed RADOLAN composite.
+func ReadRadolanComposite(r io.Reader) (*RadolanComposite, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.ReadString(radolanHeaderEnd)
+	if err != nil {
+		return nil, errMalformedRadolanHeader
+	}
+
+	composite, err := parseRadolanHeader(strings.TrimSuffix(header, string(rune(radolanHeaderEnd))))
+	if err != nil {
+		return nil, err
+	}
+
+	composite.pixels = make([]uint16, composite.Rows*composite.Columns)
+	if err := binary.Read(reader, binary.LittleEndian, composite.pixels); err != nil {
+		return nil, err
+	}
+
+	return composite, nil
+}
+
+// parseRadolanHeader parses the ASCII header of a composite.
+// The header starts with the product code, the day, time, station and month
+// of the composite (e.g. RW010050100000125) which is followed by fields
+// identified by their names.
+func parseRadolanHeader(header string) (*RadolanComposite, error) {
+	if len(header) < radolanHeaderMinimumLength {
+		return nil, errMalformedRadolanHeader
+	}
+
+	composite := &RadolanComposite{
+		Product:   header[0:2],
+		Precision: 1,
+	}
+
+	// the header contains the day and time in front of the station id and
+	// the month and year behind it
+	timestamp := header[2:8] + header[13:17]
+	var err error
+	composite.Timestamp, err = time.Parse(df_RadolanHeader, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedRadolanHeader, err)
+	}
+
+	// the free text of the header may contain the names of the fields as
+	// well, so it is not searched for the fields
+	fields := header[radolanHeaderMinimumLength:]
+	if idx := strings.Index(fields, "MS"); idx >= 0 {
+		fields = fields[:idx]
+	}
+
+	if match := radolanPrecisionPattern.FindStringSubmatch(fields); match != nil {
+		exponent, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, errMalformedRadolanHeader
+		}
+		composite.Precision = math.Pow10(exponent)
+	}
+
+	if match := radolanIntervalPattern.FindStringSubmatch(fields); match != nil {
+		minutes, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, errMalformedRadolanHeader
+		}
+		composite.Interval = time.Duration(minutes) * time.Minute
+	}
+
+	match := radolanGridPattern.FindStringSubmatch(fields)
+	if match == nil {
+		return nil, errMalformedRadolanHeader
+	}
+	composite.Rows, _ = strconv.Atoi(match[1])
+	composite.Columns, _ = strconv.Atoi(match[2])
+	if _, supported := radolanGridOrigins[[2]int{composite.Rows, composite.Columns}]; !supported {
+		return nil, fmt.Errorf("%w: %dx%d", errUnsupportedRadolanGrid, composite.Rows, composite.Columns)
+	}
+
+	return composite, nil
+}
+
+// ReadRadolanComposites reads all composites contained in the file at the
+// path and passes them to the visitor.
+// The file may either be a single composite or a tar archive of composites,
+// each of which may be compressed using gzip.
+func ReadRadolanComposites(path string, visit func(composite *RadolanComposite) error) error {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readRadolanStream(f, visit)
+}
+
+// readRadolanStream detects the compression and packaging of the stream and
+// reads the composites contained in it.
+func readRadolanStream(r io.Reader, visit func(composite *RadolanComposite) error) error {
+	reader := bufio.NewReader(r)
+
+	magic, _ := reader.Peek(2) //nolint:mnd
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		decompressed, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer decompressed.Close()
+		return readRadolanStream(decompressed, visit)
+	}
+
+	// tar archives contain the magic "ustar" behind the name of the first
+	// entry
+	const tarMagicOffset = 257
+	block, _ := reader.Peek(tarMagicOffset + 5) //nolint:mnd
+	if len(block) < tarMagicOffset+5 || string(block[tarMagicOffset:]) != "ustar" {
+		composite, err := ReadRadolanComposite(reader)
+		if err != nil {
+			return err
+		}
+		return visit(composite)
+	}
+
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := readRadolanStream(archive, visit); err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+}
+
+// OpenRadolanArchive reads the composites contained in the file at the path
+// and extracts the precipitation of the area from them.
+// The value of each datapoint is the mean of the valid grid cells covered by
+// the area and is missing if none of the cells is valid.
+// The datapoints are read completely while opening the archive.
+// If labels are supplied, only the composites of the products with these
+// labels are read.
+func OpenRadolanArchive(path string, area GridArea, labels []string) (*Archive, error) {
+	archive := &Archive{selectedLabels: labels}
+
+	var cells []int
+	var cellGrid [2]int
+	err := ReadRadolanComposites(path, func(composite *RadolanComposite) error {
+		if !archive.selected(composite.Product) {
+			return nil
+		}
+
+		metadata, known := RadolanProducts[composite.Product]
+		if !known {
+			metadata = v2.FieldMetadata{Name: composite.Product}
+		}
+		if !slices.Contains(archive.Labels, composite.Product) {
+			archive.Labels = append(archive.Labels, composite.Product)
+			archive.Metadata = append(archive.Metadata, metadata)
+		}
+
+		if grid := [2]int{composite.Rows, composite.Columns}; cells == nil || grid != cellGrid {
+			cells = area.Cells(composite.Columns, composite.Rows, composite.Project)
+			cellGrid = grid
+			if len(cells) == 0 {
+				return ErrAreaOutsideGrid
+			}
+		}
+
+		start := composite.Timestamp.Add(-composite.Interval)
+		dp := v2.Datapoint{
+			Label:     composite.Product,
+			Timestamp: start,
+			Interval:  &v2.DateTimeRange{Start: start, End: composite.Timestamp},
+		}
+		if metadata.Unit != "" {
+			dp.Unit = &metadata.Unit
+		}
+
+		var sum float64
+		var validCells int
+		for _, idx := range cells {
+			value, valid := composite.Value(idx)
+			if !valid {
+				continue
+			}
+			sum += value
+			validCells++
+		}
+		if validCells > 0 {
+			dp.Value = sum / float64(validCells)
+		}
+
+		archive.bufferedDatapoints = append(archive.bufferedDatapoints, dp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(archive.bufferedDatapoints, func(a, b v2.Datapoint) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	for idx := range archive.Metadata {
+		for _, dp := range archive.bufferedDatapoints {
+			if dp.Label != archive.Metadata[idx].Name {
+				continue
+			}
+			if archive.Metadata[idx].ValidFrom.IsZero() {
+				archive.Metadata[idx].ValidFrom = dp.Timestamp
+			}
+			archive.Metadata[idx].ValidUntil = dp.Interval.End
+		}
+	}
+
+	return archive, nil
+}
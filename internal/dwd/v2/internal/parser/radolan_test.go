@@ -0,0 +1,329 @@
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/twpayne/go-geom"
+)
+
+// sampleRadolanHeader is the header of a RW composite for the 18th of January
+// 2024 at 08:50 UTC as published by the DWD.
+const sampleRadolanHeader = "RW180850100000124BY1620135VS 3SW   2.28.1PR E-01INT  60GP 900x 900MF 00000001MS 66<asb,boo,ros,hnr,umd,pro,ess,fld,drs,neu,nhb,oft,eis,tur,isn,fbg,mem>" //nolint:lll
+
+// encodeRadolanComposite encodes a composite with the header and the pixels.
+func encodeRadolanComposite(t *testing.T, header string, pixels []uint16) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteByte(radolanHeaderEnd)
+	if err := binary.Write(&buf, binary.LittleEndian, pixels); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// missingPixels returns the pixels of a 900 × 900 grid which are all marked as
+// missing.
+func missingPixels() []uint16 {
+	pixels := make([]uint16, 900*900)
+	for idx := range pixels {
+		pixels[idx] = radolanFlag_Missing
+	}
+	return pixels
+}
+
+func TestParseRadolanHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		product   string
+		timestamp time.Time
+		interval  time.Duration
+		precision float64
+		rows      int
+		columns   int
+		err       error
+	}{
+		{
+			name:      "hourly composite",
+			header:    sampleRadolanHeader,
+			product:   "RW",
+			timestamp: time.Date(2024, 1, 18, 8, 50, 0, 0, time.UTC),
+			interval:  time.Hour,
+			precision: 0.1,
+			rows:      900,
+			columns:   900,
+		},
+		{
+			name:      "daily composite",
+			header:    "SF181150100000124BY1620141VS 3SW   2.28.1PR E-01INT1440GP 900x 900MS 10<boo,ros>ST 92<asb 24,boo 24>",
+			product:   "SF",
+			timestamp: time.Date(2024, 1, 18, 11, 50, 0, 0, time.UTC),
+			interval:  24 * time.Hour,
+			precision: 0.1,
+			rows:      900,
+			columns:   900,
+		},
+		{
+			name:      "fields in the free text are ignored",
+			header:    "RW010050100000125BY1620135VS 3SW   2.28.1INT  60GP 900x 900MS 30<PR E-02 INT   5 GP1100x 900>",
+			product:   "RW",
+			timestamp: time.Date(2025, 1, 1, 0, 50, 0, 0, time.UTC),
+			interval:  time.Hour,
+			precision: 1,
+			rows:      900,
+			columns:   900,
+		},
+		{
+			name:      "extended grid",
+			header:    "RW010050100000125BY1980135VS 5SW   2.28.1PR E-02INT  60GP1100x 900MS 10<boo,ros>",
+			product:   "RW",
+			timestamp: time.Date(2025, 1, 1, 0, 50, 0, 0, time.UTC),
+			interval:  time.Hour,
+			precision: 0.01,
+			rows:      1100,
+			columns:   900,
+		},
+		{name: "short header", header: "RW0100501000", err: errMalformedRadolanHeader},
+		{name: "malformed timestamp", header: "RW320050100000125PR E-01INT  60GP 900x 900", err: errMalformedRadolanHeader},
+		{name: "missing grid", header: "RW010050100000125PR E-01INT  60MS 10<boo,ros>", err: errMalformedRadolanHeader},
+		{name: "unsupported grid", header: "RW010050100000125PR E-01INT  60GP 100x 100", err: errUnsupportedRadolanGrid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			composite, err := parseRadolanHeader(tt.header)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if composite.Product != tt.product || !composite.Timestamp.Equal(tt.timestamp) ||
+				composite.Interval != tt.interval || composite.Rows != tt.rows || composite.Columns != tt.columns {
+				t.Errorf("unexpected composite %+v", composite)
+			}
+			if math.Abs(composite.Precision-tt.precision) > 1e-12 {
+				t.Errorf("expected precision %v, got %v", tt.precision, composite.Precision)
+			}
+		})
+	}
+}
+
+func TestRadolanCompositeValue(t *testing.T) {
+	composite, err := ReadRadolanComposite(bytes.NewReader(encodeRadolanComposite(t, sampleRadolanHeader, func() []uint16 {
+		pixels := missingPixels()
+		copy(pixels, []uint16{
+			0x0005,
+			0x0005 | radolanFlag_Secondary,
+			0x0005 | radolanFlag_Negative,
+			0x0005 | radolanFlag_Missing,
+			0x0005 | radolanFlag_Clutter,
+			0x0FFF,
+			0x0000,
+		})
+		return pixels
+	}())))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		idx   int
+		value float64
+		valid bool
+	}{
+		{name: "plain value", idx: 0, value: 0.5, valid: true},
+		{name: "secondary flag", idx: 1, value: 0.5, valid: true},
+		{name: "negative flag", idx: 2, value: -0.5, valid: true},
+		{name: "missing flag", idx: 3, valid: false},
+		{name: "clutter flag", idx: 4, valid: false},
+		{name: "largest value", idx: 5, value: 409.5, valid: true},
+		{name: "no precipitation", idx: 6, value: 0, valid: true},
+		{name: "missing cell", idx: 7, valid: false},
+		{name: "negative index", idx: -1, valid: false},
+		{name: "index outside the grid", idx: 900 * 900, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, valid := composite.Value(tt.idx)
+			if valid != tt.valid || value != tt.value {
+				t.Errorf("expected %v (valid: %t), got %v (valid: %t)", tt.value, tt.valid, value, valid)
+			}
+		})
+	}
+}
+
+func TestRadolanProject(t *testing.T) {
+	composite := &RadolanComposite{Rows: 900, Columns: 900}
+
+	// the corners of the national composite as documented by the DWD in the
+	// description of the RADOLAN composite format
+	tests := []struct {
+		name                string
+		longitude, latitude float64
+		column, row         float64
+	}{
+		{name: "south-western corner", longitude: 3.5889, latitude: 46.9526, column: 0, row: 0},
+		{name: "north-western corner", longitude: 2.0715, latitude: 54.5877, column: 0, row: 900},
+		{name: "north-eastern corner", longitude: 15.7208, latitude: 54.7405, column: 900, row: 900},
+		{name: "south-eastern corner", longitude: 14.6209, latitude: 47.0705, column: 900, row: 0},
+		{name: "center", longitude: 9, latitude: 51, column: 450, row: 450},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, row := composite.Project(tt.longitude, tt.latitude)
+			// the corners are documented with four decimals, which is
+			// accurate to about 10 m
+			if math.Abs(column-tt.column) > 0.01 || math.Abs(row-tt.row) > 0.01 {
+				t.Errorf("expected column %v and row %v, got %v and %v", tt.column, tt.row, column, row)
+			}
+		})
+	}
+}
+
+// writeRadolanArchive writes a tar archive containing the gzip compressed
+// composites to a temporary directory and returns its path.
+func writeRadolanArchive(t *testing.T, composites map[string][]byte) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for name, composite := range composites {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		if _, err := writer.Write(composite); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := archive.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(compressed.Len()),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write(compressed.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "RW-202401.tar")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenRadolanArchive(t *testing.T) {
+	// a square of about 3 km × 3 km around the center of the grid
+	polygon, err := geom.NewPolygon(geom.XY).SetCoords([][]geom.Coord{{
+		{8.98, 50.987}, {9.02, 50.987}, {9.02, 51.013}, {8.98, 51.013}, {8.98, 50.987},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	area, err := NewGridArea(polygon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	composite := &RadolanComposite{Rows: 900, Columns: 900}
+	cells := area.Cells(composite.Columns, composite.Rows, composite.Project)
+	if len(cells) < 4 {
+		t.Fatalf("expected the area to cover several cells, got %v", cells)
+	}
+
+	// the first composite contains a clutter cell, which is excluded from
+	// the mean, the second one contains only missing cells in the area
+	first := missingPixels()
+	for idx, cell := range cells {
+		first[cell] = uint16(10 * (idx + 1))
+	}
+	first[cells[0]] |= radolanFlag_Clutter
+	var sum float64
+	for idx := range cells[1:] {
+		sum += float64(idx + 2)
+	}
+	expectedMean := sum / float64(len(cells)-1)
+
+	path := writeRadolanArchive(t, map[string][]byte{
+		"raa01-rw_10000-2401180850-dwd---bin.gz": encodeRadolanComposite(t, sampleRadolanHeader, first),
+		"raa01-rw_10000-2401180950-dwd---bin.gz": encodeRadolanComposite(t,
+			"RW180950100000124BY1620135VS 3SW   2.28.1PR E-01INT  60GP 900x 900MS 10<boo,ros>", missingPixels()),
+		"raa01-sf_10000-2401180850-dwd---bin.gz": encodeRadolanComposite(t,
+			"SF180850100000124BY1620135VS 3SW   2.28.1PR E-01INT1440GP 900x 900MS 10<boo,ros>", missingPixels()),
+	})
+
+	archive, err := OpenRadolanArchive(path, area, []string{"RW"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	if len(archive.Labels) != 1 || archive.Labels[0] != "RW" {
+		t.Fatalf("expected only the RW label, got %v", archive.Labels)
+	}
+	metadata := archive.Metadata[0]
+	if !metadata.ValidFrom.Equal(time.Date(2024, 1, 18, 7, 50, 0, 0, time.UTC)) ||
+		!metadata.ValidUntil.Equal(time.Date(2024, 1, 18, 9, 50, 0, 0, time.UTC)) {
+		t.Errorf("unexpected validity %s - %s", metadata.ValidFrom, metadata.ValidUntil)
+	}
+
+	var values []any
+	for dp, err := range archive.Datapoints() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dp.Interval == nil || dp.Interval.End.Sub(dp.Interval.Start) != time.Hour ||
+			!dp.Timestamp.Equal(dp.Interval.Start) {
+			t.Errorf("unexpected interval %v for %s", dp.Interval, dp.Timestamp)
+		}
+		if dp.Unit == nil || *dp.Unit != "mm" {
+			t.Errorf("expected unit mm, got %v", dp.Unit)
+		}
+		values = append(values, dp.Value)
+	}
+
+	if len(values) != 2 {
+		t.Fatalf("expected two datapoints, got %v", values)
+	}
+	if mean, isFloat := values[0].(float64); !isFloat || math.Abs(mean-expectedMean) > 1e-9 {
+		t.Errorf("expected mean %v, got %v", expectedMean, values[0])
+	}
+	if values[1] != nil {
+		t.Errorf("expected a missing value for a composite without valid cells, got %v", values[1])
+	}
+
+	outside, err := NewGridArea(geom.NewPointFlat(geom.XY, []float64{-30, 10}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenRadolanArchive(path, outside, nil); !errors.Is(err, ErrAreaOutsideGrid) {
+		t.Errorf("expected %v for an area outside the grid, got %v", ErrAreaOutsideGrid, err)
+	}
+}
@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v2 "microservice/types/v2"
+)
+
+const (
+	regionalAverageColumn_Year   = "Jahr"
+	regionalAverageColumn_Month  = "Monat"
+	regionalAverageColumn_Season = "Jahreszeit"
+
+	regionalAveragePeriod_Year   = "year"
+	regionalAveragePeriod_Winter = "winter"
+	regionalAveragePeriod_Spring = "spring"
+	regionalAveragePeriod_Summer = "summer"
+	regionalAveragePeriod_Autumn = "autumn"
+
+	// regionalAverageMissingValue marks missing averages.
+	regionalAverageMissingValue = -999
+)
+
+// regionalAverageFileNamePattern matches the names of the regional average
+// files, which end with the month, season or year covered by their rows, e.g.
+// regional_averages_tm_01.txt or regional_averages_rr_winter.txt.
+var regionalAverageFileNamePattern = regexp.MustCompile(`(?i)^regional_averages_[a-z0-9]+_(\d{2}|year|winter|spring|summer|autumn)\.txt$`) //nolint:lll
+
+var (
+	errMalformedRegionalAverages = errors.New("malformed regional averages")
+	errUnknownRegion             = errors.New("the region is not contained in the regional averages")
+)
+
+// regionalAverageTable contains the rows of a regional average file.
+// The files start with a description line, which is followed by a table with
+// the year (and the month or season) in front of a column per region.
+type regionalAverageTable struct {
+	regions []string
+	columns []int
+	years   []int
+	rows    [][]string
+}
+
+// RegionID converts the name of a region into the id used to select it.
+// The names of the combined regions contain slashes (e.g. Brandenburg/Berlin),
+// which are replaced by dashes to allow using the ids in urls.
+func RegionID(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(name), "/", "-")
+}
+
+// RegionalAverageInterval returns the interval covered by the rows of the
+// regional average file in the year.
+// The winter of a year starts in December of the previous year.
+func RegionalAverageInterval(name string, year int) (interval v2.DateTimeRange, err error) {
+	match := regionalAverageFileNamePattern.FindStringSubmatch(path.Base(name))
+	if match == nil {
+		return interval, errMalformedRegionalAverages
+	}
+
+	var months int
+	switch strings.ToLower(match[1]) {
+	case regionalAveragePeriod_Year:
+		interval.Start, months = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), 12 //nolint:mnd
+	case regionalAveragePeriod_Winter:
+		interval.Start, months = time.Date(year-1, time.December, 1, 0, 0, 0, 0, time.UTC), 3 //nolint:mnd
+	case regionalAveragePeriod_Spring:
+		interval.Start, months = time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC), 3 //nolint:mnd
+	case regionalAveragePeriod_Summer:
+		interval.Start, months = time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC), 3 //nolint:mnd
+	case regionalAveragePeriod_Autumn:
+		interval.Start, months = time.Date(year, time.September, 1, 0, 0, 0, 0, time.UTC), 3 //nolint:mnd
+	default:
+		month, _ := strconv.Atoi(match[1])
+		if month < 1 || month > 12 {
+			return interval, errMalformedRegionalAverages
+		}
+		interval.Start, months = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), 1
+	}
+
+	interval.End = interval.Start.AddDate(0, months, -1)
+	return interval, nil
+}
+
+// readRegionalAverageTable reads the regional average file at the path.
+func readRegionalAverageTable(path string) (*regionalAverageTable, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	table := &regionalAverageTable{}
+	headerFound := false
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		// the description line in front of the header is skipped
+		if !headerFound {
+			if strings.TrimSpace(record[0]) != regionalAverageColumn_Year {
+				continue
+			}
+			headerFound = true
+
+			for idx, column := range record {
+				switch column = strings.TrimSpace(column); column {
+				case "", regionalAverageColumn_Year, regionalAverageColumn_Month, regionalAverageColumn_Season:
+					continue
+				default:
+					table.regions = append(table.regions, column)
+					table.columns = append(table.columns, idx)
+				}
+			}
+			continue
+		}
+
+		year, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			continue
+		}
+		table.years = append(table.years, year)
+		table.rows = append(table.rows, record)
+	}
+
+	if !headerFound || len(table.regions) == 0 {
+		return nil, errMalformedRegionalAverages
+	}
+	return table, nil
+}
+
+// ReadRegionalAverageRegions reads the names of the regions contained in the
+// regional average file at the path and the range covered by its rows.
+// The name of the file on the OpenData Portal is used to determine the
+// intervals covered by the rows.
+func ReadRegionalAverageRegions(path, name string) (regions []string, available v2.DateTimeRange, err error) {
+	table, err := readRegionalAverageTable(path)
+	if err != nil {
+		return nil, available, err
+	}
+	if len(table.years) == 0 {
+		return table.regions, available, nil
+	}
+
+	first, err := RegionalAverageInterval(name, table.years[0])
+	if err != nil {
+		return nil, available, err
+	}
+	last, err := RegionalAverageInterval(name, table.years[len(table.years)-1])
+	if err != nil {
+		return nil, available, err
+	}
+
+	return table.regions, v2.DateTimeRange{Start: first.Start, End: last.End}, nil
+}
+
+// OpenRegionalAverages reads the averages of the region with the id from the
+// regional average file at the path.
+// The name of the file on the OpenData Portal is used to determine the
+// intervals covered by the rows, which are attached to the datapoints.
+// Missing averages are returned as datapoints without a value.
+func OpenRegionalAverages(path, name, regionID string, value GridValue, labels []string) (*Archive, error) {
+	archive := &Archive{selectedLabels: labels}
+	if !archive.selected(value.Metadata.Name) {
+		return archive, nil
+	}
+
+	table, err := readRegionalAverageTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	column := -1
+	for idx, region := range table.regions {
+		if RegionID(region) == regionID {
+			column = table.columns[idx]
+			break
+		}
+	}
+	if column < 0 {
+		return nil, errUnknownRegion
+	}
+
+	metadata := value.Metadata
+	for idx, row := range table.rows {
+		interval, err := RegionalAverageInterval(name, table.years[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if idx == 0 {
+			metadata.ValidFrom = interval.Start
+		}
+		metadata.ValidUntil = interval.End
+
+		dp := v2.Datapoint{
+			Label:     metadata.Name,
+			Timestamp: interval.Start,
+			Interval:  &interval,
+		}
+		if metadata.Unit != "" {
+			dp.Unit = &metadata.Unit
+		}
+		if column < len(row) {
+			average, err := strconv.ParseFloat(strings.TrimSpace(row[column]), 64)
+			if err == nil && average != regionalAverageMissingValue {
+				dp.Value = scaleValue(average, value.Factor)
+			}
+		}
+		archive.bufferedDatapoints = append(archive.bufferedDatapoints, dp)
+	}
+
+	archive.Metadata = []v2.FieldMetadata{metadata}
+	archive.Labels = []string{metadata.Name}
+	return archive, nil
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// semicolonTable is a semicolon separated table with a header, as used by the
+// phenology and CLIMAT databases.
+// The columns are looked up by their names, since the tables differ in the
+// columns they contain.
+type semicolonTable struct {
+	reader  *csv.Reader
+	header  []string
+	columns map[string]int
+}
+
+// openSemicolonTable reads the header of the table.
+func openSemicolonTable(r io.Reader) (*semicolonTable, error) {
+	reader := csv.NewReader(transform.NewReader(r, charmap.Windows1252.NewDecoder().Transformer))
+	reader.TrimLeadingSpace = true
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	table := &semicolonTable{reader: reader, header: header, columns: make(map[string]int)}
+	for idx, column := range header {
+		header[idx] = strings.TrimSpace(column)
+		table.columns[header[idx]] = idx
+	}
+	return table, nil
+}
+
+// hasColumns checks if the table contains all columns.
+func (t *semicolonTable) hasColumns(columns ...string) bool {
+	for _, column := range columns {
+		if _, found := t.columns[column]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// findColumn returns the name of the first candidate column contained in the
+// table, ignoring the case of the names.
+func (t *semicolonTable) findColumn(candidates ...string) (string, bool) {
+	for _, candidate := range candidates {
+		for _, column := range t.header {
+			if strings.EqualFold(column, candidate) {
+				return column, true
+			}
+		}
+	}
+	return "", false
+}
+
+// rows iterates over the rows of the table.
+// The values of the rows are trimmed and accessed using the column names,
+// returning an empty string for missing columns.
+func (t *semicolonTable) rows(yield func(row func(column string) string, err error) bool) {
+	for {
+		line, err := t.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		row := func(column string) string {
+			idx, found := t.columns[column]
+			if !found || idx >= len(line) {
+				return ""
+			}
+			return strings.TrimSpace(line[idx])
+		}
+		if !yield(row, nil) {
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// xmlCharsetReader decodes the XML documents published by the DWD (e.g. the
+// MOSMIX KML documents and the CAP alerts), which are not always encoded in
+// UTF-8 but declare ISO-8859-1 or Windows-1252 instead.
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		return transform.NewReader(input, charmap.ISO8859_1.NewDecoder()), nil
+	case "windows-1252":
+		return transform.NewReader(input, charmap.Windows1252.NewDecoder()), nil
+	default:
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+}
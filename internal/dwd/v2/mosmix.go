@@ -0,0 +1,253 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/sync/singleflight"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	"microservice/internal/redis"
+	v2 "microservice/types/v2"
+)
+
+const (
+	MosmixUrlKey  = "mosmix"
+	MosmixBaseUrl = "https://opendata.dwd.de/weather/local_forecasts/mos/"
+)
+
+const (
+	// redisKeyPattern_MosmixRun is used to generate the redis key marking a
+	// run of a product as completely cached.
+	redisKeyPattern_MosmixRun = "dwd-v2-mosmix:%s:%s"
+
+	// redisKeyPattern_MosmixForecast is used to generate the redis key under
+	// which the forecast of a station in a run is stored.
+	redisKeyPattern_MosmixForecast = redisKeyPattern_MosmixRun + ":%s"
+)
+
+// mosmixRunTTL is the time a cached run is kept.
+// MOSMIX_S is issued hourly, so a run is superseded long before it expires.
+const mosmixRunTTL = 3 * time.Hour
+
+// mosmixRunBatchSize is the number of forecasts written to redis at once while
+// caching a run.
+const mosmixRunBatchSize = 500
+
+// mosmixRuns deduplicates concurrent caching of the same run.
+var mosmixRuns singleflight.Group
+
+// Mosmix contains the MOSMIX point forecasts for the MOSMIX stations.
+// The forecasts are published as KMZ archives containing a KML document with
+// the forecasts of all elements for each station.
+// MOSMIX_L is published four times a day for each station separately, while
+// MOSMIX_S is published hourly for all stations in a single archive, which is
+// split into the forecasts of the stations once per run and cached in redis.
+var Mosmix ForecastDatabase = mosmix{}
+
+// AvailableMosmixProducts contains a mapping of the MOSMIX products to the
+// available granularities.
+var AvailableMosmixProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Hourly: {
+		dwdTypes.Forecast_MosmixL,
+		dwdTypes.Forecast_MosmixS,
+	},
+}
+
+type mosmix struct{}
+
+func (mosmix) Name() string {
+	return MosmixUrlKey
+}
+
+func (mosmix) BaseUrl() string {
+	return MosmixBaseUrl
+}
+
+func (mosmix) Products() map[Granularity][]Product {
+	return AvailableMosmixProducts
+}
+
+func (mosmix) Forecasts() {}
+
+// allStationsUrl returns the url of the archive containing the latest forecast
+// for all stations.
+func (db mosmix) allStationsUrl(product Product) (string, error) {
+	switch product {
+	case dwdTypes.Forecast_MosmixL:
+		return url.JoinPath(db.BaseUrl(), product.UrlPart(), "all_stations/kml/MOSMIX_L_LATEST.kmz")
+	case dwdTypes.Forecast_MosmixS:
+		return url.JoinPath(db.BaseUrl(), product.UrlPart(), "all_stations/kml/MOSMIX_S_LATEST_240.kmz")
+	default:
+		return "", errUnsupportedProduct
+	}
+}
+
+func (db mosmix) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	if !SupportsProduct(db, granularity, product) {
+		return nil, errUnsupportedProduct
+	}
+
+	uri, err := db.allStationsUrl(product)
+	if err != nil {
+		return nil, err
+	}
+
+	filepath, err := dwd.Download(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer dwd.Release(filepath)
+
+	stations, forecastRange, err := parser.ReadMosmixStations(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uri, err)
+	}
+
+	for idx := range stations {
+		stations[idx].SupportedProducts = map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+			product: {
+				granularity: forecastRange,
+			},
+		}
+	}
+
+	return stations, nil
+}
+
+// DownloadFiles downloads the latest forecast containing the station.
+// The MOSMIX_L forecasts are downloaded for the station only, while the
+// MOSMIX_S forecasts are only available for all stations at once.
+// The forecasts are not split into periods and are not limited by the filter.
+func (db mosmix) DownloadFiles(stationID string, product Product, granularity Granularity, _ DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	var uri string
+	var err error
+	switch product {
+	case dwdTypes.Forecast_MosmixL:
+		uri, err = url.JoinPath(db.BaseUrl(), product.UrlPart(), "single_stations", stationID, "kml",
+			"MOSMIX_L_LATEST_"+stationID+".kmz")
+	default:
+		uri, err = db.allStationsUrl(product)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filepath, err := dwd.Download(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []DataFile{{Path: filepath, Name: path.Base(uri)}}, nil, nil
+}
+
+func (mosmix) OpenDataFile(file DataFile, stationID string, product Product, _ Granularity, labels []string) (*Archive, error) { //nolint:lll
+	if product == dwdTypes.Forecast_MosmixS {
+		return openCachedMosmixForecast(context.Background(), file.Path, stationID, product, labels)
+	}
+	return parser.OpenMosmixForecast(file.Path, stationID, labels)
+}
+
+// openCachedMosmixForecast reads the forecast of the station from the cached
+// run the forecast at the path belongs to.
+// The MOSMIX_S forecasts of all stations are contained in a single document,
+// which is split into the forecasts of the stations once per issue time
+// instead of being read for every request.
+func openCachedMosmixForecast(ctx context.Context, path, stationID string, product Product, labels []string) (*Archive, error) { //nolint:lll
+	issueTime, err := parser.ReadMosmixIssueTime(path)
+	if err != nil {
+		return nil, err
+	}
+
+	runKey := mosmixRunKey(product, issueTime)
+	cached, err := redis.Client().Exists(ctx, runKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if cached == 0 {
+		_, err, _ := mosmixRuns.Do(runKey, func() (any, error) {
+			// the run is shared, so caching it may not depend on the request
+			// that triggered it
+			return nil, cacheMosmixRun(context.WithoutCancel(ctx), path, product, issueTime)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := redis.Client().Get(ctx, mosmixForecastKey(product, issueTime, stationID)).Bytes()
+	if redis.IsNotFound(err) {
+		return parser.MosmixForecast{StationID: stationID}.Archive(labels), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var forecast parser.MosmixForecast
+	if err := json.NewDecoder(brotli.NewReader(bytes.NewReader(payload))).Decode(&forecast); err != nil {
+		return nil, err
+	}
+	return forecast.Archive(labels), nil
+}
+
+// cacheMosmixRun splits the forecast at the path into the forecasts of the
+// stations and stores them in redis.
+// The run is only marked as cached after all forecasts have been stored.
+func cacheMosmixRun(ctx context.Context, path string, product Product, issueTime time.Time) error {
+	runKey := mosmixRunKey(product, issueTime)
+	// another caller may have finished caching the run in the meantime
+	cached, err := redis.Client().Exists(ctx, runKey).Result()
+	if err != nil || cached > 0 {
+		return err
+	}
+
+	start := time.Now()
+	pipeline := redis.Client().Pipeline()
+	err = parser.ReadMosmixForecasts(path, func(forecast parser.MosmixForecast) error {
+		var buf bytes.Buffer
+		writer := brotli.NewWriter(&buf)
+		if err := json.NewEncoder(writer).Encode(forecast); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		pipeline.Set(ctx, mosmixForecastKey(product, issueTime, forecast.StationID), buf.Bytes(), mosmixRunTTL)
+		if pipeline.Len() < mosmixRunBatchSize {
+			return nil
+		}
+		_, err := pipeline.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		pipeline.Discard()
+		return err
+	}
+	if _, err := pipeline.Exec(ctx); err != nil {
+		return err
+	}
+
+	// the marker may not outlive the forecasts written first
+	return redis.Client().Set(ctx, runKey, issueTime.Format(time.RFC3339), mosmixRunTTL-time.Since(start)).Err()
+}
+
+func mosmixRunKey(product Product, issueTime time.Time) string {
+	return fmt.Sprintf(redisKeyPattern_MosmixRun, product.String(), issueTime.UTC().Format(time.RFC3339))
+}
+
+func mosmixForecastKey(product Product, issueTime time.Time, stationID string) string {
+	return fmt.Sprintf(redisKeyPattern_MosmixForecast, product.String(), issueTime.UTC().Format(time.RFC3339), stationID)
+}
@@ -0,0 +1,267 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+// multiAnnualFolderPrefix is the prefix of the folders containing the means
+// of a single reference period (e.g. mean_61-90, mean_91-20).
+const multiAnnualFolderPrefix = "mean_"
+
+// multiAnnualParameter describes the files of a product in the multi-annual
+// granularity.
+type multiAnnualParameter struct {
+	// FilePrefix is the prefix of the mean files in the reference period
+	// folders.
+	FilePrefix string
+
+	// Description describes the parameter, since the mean files contain no
+	// metadata.
+	Description string
+
+	// Unit contains the unit of the means.
+	Unit string
+
+	// StationList is the path of the station list, relative to the database,
+	// which describes the stations contained in the mean files.
+	// The multi-annual folder contains no station lists, so the list of the
+	// daily observations the means are calculated from is used instead.
+	StationList string
+}
+
+// multiAnnualParameters maps the products available in the multi-annual
+// granularity to the description of their files.
+var multiAnnualParameters = map[Product]multiAnnualParameter{
+	dwdTypes.ClimateObservation_AirTemperature: {
+		FilePrefix:  "Temperatur",
+		Description: "Mittelwert der Lufttemperatur",
+		Unit:        "°C",
+		StationList: "daily/kl/historical/KL_Tageswerte_Beschreibung_Stationen.txt",
+	},
+	dwdTypes.ClimateObservation_Precipitation: {
+		FilePrefix:  "Niederschlag",
+		Description: "Mittelwert der Niederschlagshöhe",
+		Unit:        "mm",
+		StationList: "daily/more_precip/historical/RR_Tageswerte_Beschreibung_Stationen.txt",
+	},
+	dwdTypes.ClimateObservation_Sun: {
+		FilePrefix:  "Sonnenscheindauer",
+		Description: "Mittelwert der Sonnenscheindauer",
+		Unit:        "h",
+		StationList: "daily/kl/historical/KL_Tageswerte_Beschreibung_Stationen.txt",
+	},
+}
+
+// matches checks if the file contains the means of the parameter.
+// The prefix is followed by the reference period (e.g. Temperatur_1991-2020),
+// which distinguishes the files from the ones of related parameters.
+func (p multiAnnualParameter) matches(file string) bool {
+	rest, found := strings.CutPrefix(file, p.FilePrefix+"_")
+	if !found || !strings.HasSuffix(file, ".txt") || rest == "" {
+		return false
+	}
+	return rest[0] >= '0' && rest[0] <= '9'
+}
+
+// multiAnnualFiles lists the urls of the mean files of the product in all
+// reference period folders and the description files placed next to them.
+func multiAnnualFiles(databaseUrl string, product Product) (dataFiles []string, descriptionFiles [][2]string, err error) { //nolint:lll
+	parameter, supported := multiAnnualParameters[product]
+	if !supported {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	uri, err := url.JoinPath(databaseUrl, dwdTypes.Granularity_MultiAnnual.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, folders, err := readFolder(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	var l sync.Mutex
+
+	for _, folder := range folders {
+		if !strings.HasPrefix(folder, multiAnnualFolderPrefix) {
+			continue
+		}
+
+		group.Go(func() error {
+			uri, err := url.JoinPath(uri, folder)
+			if err != nil {
+				return err
+			}
+			files, _, err := readFolder(uri)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range files {
+				fileUri, err := url.JoinPath(uri, file)
+				if err != nil {
+					return err
+				}
+
+				l.Lock()
+				switch {
+				case parameter.matches(file):
+					dataFiles = append(dataFiles, fileUri)
+				case strings.HasSuffix(file, ".pdf"):
+					descriptionFiles = append(descriptionFiles, [2]string{file, fileUri})
+				}
+				l.Unlock()
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+// downloadMultiAnnualFiles downloads the mean files of the product and their
+// descriptions.
+// The mean files contain the means of all stations, so the station is
+// selected when opening them.
+func downloadMultiAnnualFiles(databaseUrl string, product Product) (datafiles []DataFile, descriptions [][2]string, err error) { //nolint:lll
+	dataFileUrls, descriptionFiles, err := multiAnnualFiles(databaseUrl, product)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, uri := range dataFileUrls {
+		filepath, err := dwd.Download(uri)
+		if err != nil {
+			ReleaseFiles(datafiles, nil)
+			return nil, nil, err
+		}
+		datafiles = append(datafiles, DataFile{Path: filepath, Name: path.Base(uri)})
+	}
+
+	for _, descriptionFile := range descriptionFiles {
+		filepath, err := dwd.Download(descriptionFile[1])
+		if err != nil {
+			ReleaseFiles(datafiles, descriptions)
+			return nil, nil, err
+		}
+		descriptions = append(descriptions, [2]string{descriptionFile[0], filepath})
+	}
+
+	return datafiles, descriptions, nil
+}
+
+// discoverMultiAnnualStations discovers the stations for which the means of
+// the product are available.
+// The stations are read from the mean files and described using the station
+// list of the daily observations.
+func discoverMultiAnnualStations(databaseUrl string, product Product) ([]v2.Station, error) {
+	parameter, supported := multiAnnualParameters[product]
+	if !supported {
+		return nil, errUnsupportedProduct
+	}
+
+	dataFileUrls, _, err := multiAnnualFiles(databaseUrl, product)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make(map[string]v2.DateTimeRange)
+	for _, uri := range dataFileUrls {
+		res, err := http.Get(uri) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("%s: %w", uri, errStatusNotOK)
+		}
+
+		stationPeriods, err := parser.ReadMultiAnnualStations(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", uri, err)
+		}
+
+		for stationID, referencePeriods := range stationPeriods {
+			for _, referencePeriod := range referencePeriods {
+				available, known := periods[stationID]
+				if !known {
+					periods[stationID] = referencePeriod
+					continue
+				}
+
+				if referencePeriod.Start.Before(available.Start) {
+					available.Start = referencePeriod.Start
+				}
+				if referencePeriod.End.After(available.End) {
+					available.End = referencePeriod.End
+				}
+				periods[stationID] = available
+			}
+		}
+	}
+
+	uri, err := url.JoinPath(databaseUrl, parameter.StationList)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.Get(uri) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %w", uri, errStatusNotOK)
+	}
+
+	parsedStations, _, err := parser.ParseStationList(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var stations []v2.Station
+	for _, station := range parsedStations {
+		available, found := periods[station.ID]
+		if !found {
+			continue
+		}
+
+		station.SupportedProducts = map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+			product: {
+				dwdTypes.Granularity_MultiAnnual: available,
+			},
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, nil
+}
+
+// OpenMultiAnnualMeans reads the multi-annual means of the product for the
+// station from the downloaded mean file.
+// If labels are supplied, only the means with these labels are read.
+func OpenMultiAnnualMeans(file DataFile, stationID string, product Product, labels []string) (*Archive, error) {
+	parameter, supported := multiAnnualParameters[product]
+	if !supported {
+		return nil, errUnsupportedProduct
+	}
+	return parser.OpenMultiAnnualMeans(file.Path, stationID, parameter.Description, parameter.Unit, labels)
+}
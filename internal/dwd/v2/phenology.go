@@ -0,0 +1,342 @@
+package v2
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal"
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	PhenologyUrlKey  = "phenology"
+	PhenologyBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/observations_germany/phenology/"
+)
+
+const (
+	// phenologyReportersFolder contains the reports of the annual reporters,
+	// which report the phases observed during a year after its end.
+	phenologyReportersFolder = "annual_reporters"
+
+	phenologyStationList = "help/PH_Beschreibung_Phaenologie_Stationen_Jahresmelder.txt"
+	phenologyPlantTable  = "help/PH_Beschreibung_Pflanze.txt"
+	phenologyPhaseTable  = "help/PH_Beschreibung_Phase.txt"
+)
+
+// phenologyReportPattern matches the names of the report files, which end with
+// the years covered by the historical reports or mark the recent reports, e.g.
+// PH_Jahresmelder_Landwirtschaft_Kulturpflanze_Mais_1936_2023_hist.txt.
+var phenologyReportPattern = regexp.MustCompile(`(?i)^PH_Jahresmelder_.+?(?:_(\d{4})_(\d{4})_hist|_akt)\.txt$`)
+
+// Phenology contains the phenological observations of the annual reporters.
+// The observations are published as semicolon separated report files per
+// plant, which contain the dates the phases of the plant have been observed
+// on at all stations.
+// The plants and phases are identified by codes described in separate code
+// tables.
+var Phenology Database = phenology{}
+
+// AvailablePhenologyProducts contains a mapping of the plant groups to the
+// available granularities.
+var AvailablePhenologyProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Annual: {
+		dwdTypes.Phenology_Crops,
+		dwdTypes.Phenology_Farming,
+		dwdTypes.Phenology_Fruit,
+		dwdTypes.Phenology_Vine,
+		dwdTypes.Phenology_Wild,
+	},
+}
+
+// phenologyCodeTables contains the names of the plants and phases referenced
+// by their codes in the report files.
+type phenologyCodeTables struct {
+	plants, phases map[int]string
+}
+
+// cachedPhenologyCodes contains a code table read from the OpenData Portal.
+type cachedPhenologyCodes struct {
+	codes   map[int]string
+	fetched time.Time
+}
+
+// phenologyCodes caches the code tables by their uri, since they are shared by
+// all report files and read for every opened report file.
+// The code tables are kept for the maximum age of the downloaded files.
+var (
+	phenologyCodes     = make(map[string]cachedPhenologyCodes)
+	phenologyCodesLock sync.Mutex
+)
+
+type phenology struct{}
+
+func (phenology) Name() string {
+	return PhenologyUrlKey
+}
+
+func (phenology) BaseUrl() string {
+	return PhenologyBaseUrl
+}
+
+func (phenology) Products() map[Granularity][]Product {
+	return AvailablePhenologyProducts
+}
+
+// DiscoverStations reads the station list of the annual reporters and returns
+// the stations contained in the report files of the product together with the
+// range of their observations.
+func (db phenology) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	if !SupportsProduct(db, granularity, product) {
+		return nil, errUnsupportedProduct
+	}
+
+	stationListUri, err := url.JoinPath(db.BaseUrl(), phenologyStationList)
+	if err != nil {
+		return nil, err
+	}
+	stationList, err := dwd.Download(stationListUri)
+	if err != nil {
+		return nil, err
+	}
+	defer dwd.Release(stationList)
+	f, err := os.Open(stationList) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stations, err := parser.ReadPhenologyStations(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", stationListUri, err)
+	}
+
+	reports, descriptions, err := db.listReports(product, DownloadFilter{})
+	if err != nil {
+		return nil, err
+	}
+	ReleaseFiles(nil, descriptions)
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	availability := make(map[string]v2.DateTimeRange)
+
+	for _, report := range reports {
+		group.Go(func() error {
+			filepath, err := dwd.Download(report.uri)
+			if err != nil {
+				return err
+			}
+			defer dwd.Release(filepath)
+			f, err := os.Open(filepath) //nolint:gosec
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			reportAvailability, err := parser.ReadPhenologyAvailability(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", report.uri, err)
+			}
+
+			l.Lock()
+			defer l.Unlock()
+			for id, available := range reportAvailability {
+				known, found := availability[id]
+				if found && known.Start.Before(available.Start) {
+					available.Start = known.Start
+				}
+				if found && known.End.After(available.End) {
+					available.End = known.End
+				}
+				availability[id] = available
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	var result []v2.Station
+	for _, station := range stations {
+		available, found := availability[station.ID]
+		if !found {
+			continue
+		}
+		station.SupportedProducts = map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+			product: {
+				granularity: available,
+			},
+		}
+		result = append(result, station)
+	}
+
+	return result, nil
+}
+
+// DownloadFiles downloads the report files of the product.
+// The report files contain the observations of all stations, so the files are
+// only limited by the periods of the filter and the years covered by the
+// historical reports.
+func (db phenology) DownloadFiles(_ string, product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	reports, descriptions, err := db.listReports(product, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	dataFiles := make([]DataFile, len(reports))
+
+	for idx, report := range reports {
+		group.Go(func() error {
+			filepath, err := dwd.Download(report.uri)
+			if err != nil {
+				return err
+			}
+			dataFiles[idx] = DataFile{Path: filepath, Name: report.name, Period: report.period}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, descriptions)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptions, nil
+}
+
+// OpenDataFile reads the observations of the station from the report file.
+// The names of the plants and phases are taken from the cached code tables.
+func (db phenology) OpenDataFile(file DataFile, stationID string, _ Product, _ Granularity, labels []string) (*Archive, error) { //nolint:lll
+	codeTables, err := db.readCodeTables()
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := parser.OpenPhenologyObservations(file.Path, stationID, codeTables.plants, codeTables.phases, labels) //nolint:lll
+	if err != nil {
+		return nil, err
+	}
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// readCodeTables downloads and reads the code tables of the plants and
+// phases.
+func (db phenology) readCodeTables() (*phenologyCodeTables, error) {
+	plants, err := db.readCodeTable(phenologyPlantTable, parser.ReadPhenologyPlants)
+	if err != nil {
+		return nil, err
+	}
+	phases, err := db.readCodeTable(phenologyPhaseTable, parser.ReadPhenologyPhases)
+	if err != nil {
+		return nil, err
+	}
+	return &phenologyCodeTables{plants: plants, phases: phases}, nil
+}
+
+// readCodeTable downloads and reads the code table at the path, which is
+// relative to the database.
+// Code tables read within the maximum age of the downloaded files are taken
+// from the cache.
+func (db phenology) readCodeTable(tablePath string, read func(r io.Reader) (map[int]string, error)) (map[int]string, error) { //nolint:lll
+	uri, err := url.JoinPath(db.BaseUrl(), tablePath)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge := internal.Configuration().GetDuration(internal.ConfigKey_Cache_MaxAge)
+	phenologyCodesLock.Lock()
+	cached, found := phenologyCodes[uri]
+	phenologyCodesLock.Unlock()
+	if found && time.Since(cached.fetched) < maxAge {
+		return cached.codes, nil
+	}
+	filepath, err := dwd.Download(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer dwd.Release(filepath)
+	f, err := os.Open(filepath) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	codes, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uri, err)
+	}
+
+	phenologyCodesLock.Lock()
+	phenologyCodes[uri] = cachedPhenologyCodes{codes: codes, fetched: time.Now()}
+	phenologyCodesLock.Unlock()
+	return codes, nil
+}
+
+// listReports lists the report files of the product in the period folders
+// selected by the filter and downloads the description files placed in the
+// product folder.
+func (db phenology) listReports(product Product, filter DownloadFilter) ([]periodFile, [][2]string, error) {
+	productUri, err := url.JoinPath(db.BaseUrl(), phenologyReportersFolder, product.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, folders, err := readFolder(productUri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reports, err := listPeriodFiles(productUri, folders, filter.Periods)
+	if err != nil {
+		return nil, nil, err
+	}
+	reports = slices.DeleteFunc(reports, func(report periodFile) bool {
+		return !phenologyReportOverlaps(report.name, filter)
+	})
+
+	descriptions, err := downloadDescriptionFiles(productUri, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reports, descriptions, nil
+}
+
+// phenologyReportOverlaps checks if the file is a report file which may
+// contain observations in the range of the filter.
+// The recent reports carry no years in their names and always overlap.
+func phenologyReportOverlaps(file string, filter DownloadFilter) bool {
+	match := phenologyReportPattern.FindStringSubmatch(file)
+	if match == nil {
+		return false
+	}
+	if match[1] == "" {
+		return true
+	}
+
+	first, _ := strconv.Atoi(match[1])
+	last, _ := strconv.Atoi(match[2])
+	return yearOverlaps(first, time.Time{}, filter.End) && yearOverlaps(last, filter.Start, time.Time{})
+}
@@ -0,0 +1,42 @@
+package v2
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"microservice/internal"
+)
+
+func TestReadCodeTableCached(t *testing.T) {
+	if err := internal.ParseConfiguration(); err != nil {
+		t.Fatal(err)
+	}
+	internal.Configuration().Set(internal.ConfigKey_Cache_MaxAge, time.Hour)
+
+	uri, err := url.JoinPath(PhenologyBaseUrl, phenologyPlantTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phenologyCodesLock.Lock()
+	phenologyCodes[uri] = cachedPhenologyCodes{codes: map[int]string{215: "Mais"}, fetched: time.Now()}
+	phenologyCodesLock.Unlock()
+	t.Cleanup(func() {
+		phenologyCodesLock.Lock()
+		delete(phenologyCodes, uri)
+		phenologyCodesLock.Unlock()
+	})
+
+	// the cached code table is returned without reading the code table again
+	codes, err := phenology{}.readCodeTable(phenologyPlantTable, func(io.Reader) (map[int]string, error) {
+		return nil, errors.New("the code table has been read again")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codes[215] != "Mais" {
+		t.Errorf("expected the cached code table, got %v", codes)
+	}
+}
@@ -4,6 +4,7 @@ import "microservice/internal/dwd/v2/dwdTypes"
 
 type Granularity = dwdTypes.Granularity
 type Product = dwdTypes.Product
+type Period = dwdTypes.Period
 
 // AvailableClimateObservationProducts contains a mapping of the products to the
 // each of the available granularities.
@@ -46,6 +47,7 @@ var AvailableClimateObservationProducts = map[Granularity][]Product{
 		dwdTypes.ClimateObservation_WindSpeeds,
 	},
 	dwdTypes.Granularity_Daily: {
+		dwdTypes.ClimateObservation_StationObservations,
 		dwdTypes.ClimateObservation_MorePrecipitation,
 		dwdTypes.ClimateObservation_MoreWeatherPhenomena,
 		dwdTypes.ClimateObservation_SoilTemperature,
@@ -54,13 +56,20 @@ var AvailableClimateObservationProducts = map[Granularity][]Product{
 		dwdTypes.ClimateObservation_WeatherPhenomena,
 	},
 	dwdTypes.Granularity_Monthly: {
+		dwdTypes.ClimateObservation_StationObservations,
 		dwdTypes.ClimateObservation_ClimateIndices,
 		dwdTypes.ClimateObservation_MorePrecipitation,
 		dwdTypes.ClimateObservation_WeatherPhenomena,
 	},
 	dwdTypes.Granularity_Annual: {
+		dwdTypes.ClimateObservation_StationObservations,
 		dwdTypes.ClimateObservation_ClimateIndices,
 		dwdTypes.ClimateObservation_MorePrecipitation,
 		dwdTypes.ClimateObservation_WeatherPhenomena,
 	},
+	dwdTypes.Granularity_MultiAnnual: {
+		dwdTypes.ClimateObservation_AirTemperature,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.ClimateObservation_Sun,
+	},
 }
@@ -0,0 +1,157 @@
+package v2
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+var ErrUnknownQualityAction = errors.New("unknown quality action")
+
+// QualityAction selects what happens to datapoints rejected by a
+// QualityFilter.
+type QualityAction int
+
+const (
+	// QualityAction_Drop removes the rejected datapoints from the timeseries.
+	QualityAction_Drop QualityAction = iota
+
+	// QualityAction_Null keeps the rejected datapoints in the timeseries but
+	// removes their values.
+	QualityAction_Null
+)
+
+func (a QualityAction) String() string {
+	switch a {
+	case QualityAction_Drop:
+		return "drop"
+	case QualityAction_Null:
+		return "null"
+	default:
+		return ""
+	}
+}
+
+func (a *QualityAction) Parse(s string) error {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case QualityAction_Drop.String():
+		*a = QualityAction_Drop
+	case QualityAction_Null.String():
+		*a = QualityAction_Null
+	default:
+		return ErrUnknownQualityAction
+	}
+	return nil
+}
+
+// QualityFilter rejects datapoints based on their quality levels.
+type QualityFilter struct {
+	// ExcludedLevels contains the quality levels which are rejected.
+	ExcludedLevels []dwdTypes.QualityControlProcedure
+
+	// MinimumQuality rejects all datapoints with a quality level ranked below
+	// it (see qualityRanking) if set.
+	MinimumQuality *dwdTypes.QualityControlProcedure
+
+	// KeepMissing keeps the datapoints without a quality level when filtering
+	// by MinimumQuality, which rejects them otherwise.
+	KeepMissing bool
+
+	// Action selects what happens to the rejected datapoints.
+	Action QualityAction
+}
+
+// Active reports if the filter rejects any datapoints at all.
+func (f QualityFilter) Active() bool {
+	return len(f.ExcludedLevels) > 0 || f.MinimumQuality != nil
+}
+
+// qualityRanking orders the quality levels from the least to the most
+// thoroughly controlled one.
+// The numbers of the levels are assigned by the DWD and are not compared
+// directly, as they only name the procedure a value has passed.
+var qualityRanking = []dwdTypes.QualityControlProcedure{
+	dwdTypes.QCP_FormalControl,
+	dwdTypes.QCP_IndividualCriteria,
+	dwdTypes.QCP_Automatic,
+	dwdTypes.QCP_HistoricSubjective,
+	dwdTypes.QCP_SecondaryControl,
+	dwdTypes.QCP_OutOfRoutine,
+	dwdTypes.QCP_SingleParameterCorrection,
+	dwdTypes.QCP_Finished,
+}
+
+// rejects checks if a datapoint with the quality level is rejected by the
+// filter.
+// A nil quality level marks a datapoint without a quality level.
+func (f QualityFilter) rejects(level *dwdTypes.QualityControlProcedure) bool {
+	if level == nil {
+		return f.MinimumQuality != nil && !f.KeepMissing
+	}
+	if slices.Contains(f.ExcludedLevels, *level) {
+		return true
+	}
+	if f.MinimumQuality == nil {
+		return false
+	}
+	return slices.Index(qualityRanking, *level) < slices.Index(qualityRanking, *f.MinimumQuality)
+}
+
+// qualityLevel_Missing is the name under which the datapoints without a
+// quality level are counted in a QualityFilterSummary.
+const qualityLevel_Missing = "missing"
+
+// QualityFilterSummary counts the datapoints rejected by a QualityFilter.
+// The counts are grouped by the label of the datapoints and the name of their
+// quality level.
+type QualityFilterSummary map[string]map[string]int
+
+func (s QualityFilterSummary) add(label string, level *dwdTypes.QualityControlProcedure) {
+	levels, found := s[label]
+	if !found {
+		levels = make(map[string]int)
+		s[label] = levels
+	}
+	if level == nil {
+		levels[qualityLevel_Missing]++
+		return
+	}
+	levels[level.String()]++
+}
+
+// FilterQuality applies the quality filter to the datapoints.
+// The returned summary is filled while the datapoints are consumed and is
+// therefore only complete once the returned sequence has been exhausted.
+func FilterQuality(datapoints iter.Seq2[v2.Datapoint, error], filter QualityFilter) (iter.Seq2[v2.Datapoint, error], QualityFilterSummary) { //nolint:lll
+	summary := make(QualityFilterSummary)
+
+	return func(yield func(v2.Datapoint, error) bool) {
+		for dp, err := range datapoints {
+			if err != nil {
+				yield(dp, err)
+				return
+			}
+
+			if !filter.rejects(dp.QualityLevel) {
+				if !yield(dp, nil) {
+					return
+				}
+				continue
+			}
+
+			summary.add(dp.Label, dp.QualityLevel)
+			if filter.Action == QualityAction_Drop {
+				continue
+			}
+
+			dp.Value = nil
+			if !yield(dp, nil) {
+				return
+			}
+		}
+	}, summary
+}
@@ -0,0 +1,230 @@
+package v2
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+)
+
+const (
+	RadolanUrlKey  = "radolan"
+	RadolanBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/grids_germany/"
+)
+
+const (
+	// radolanFolder_Binary is the folder containing the binary composites in
+	// the period folders.
+	radolanFolder_Binary = "bin"
+
+	// df_RadolanFileName is the format of the timestamps in the names of the
+	// recent composites.
+	df_RadolanFileName = "0601021504"
+
+	// df_RadolanArchiveName is the format of the months in the names of the
+	// historical archives.
+	df_RadolanArchiveName = "200601"
+)
+
+var (
+	radolanFileNamePattern    = regexp.MustCompile(`(?i)^raa01-([a-z]{2})_10000-(\d{10})-dwd---bin(\.gz)?$`)
+	radolanArchiveNamePattern = regexp.MustCompile(`(?i)^([a-z]{2})\D*?(\d{6})\.tar(\.gz)?$`)
+)
+
+// Radolan contains the RADOLAN precipitation composites, which combine the
+// radar measurements with the rain gauges.
+// The hourly RW composites and the daily SF composites are published as
+// single files in the recent folders and as monthly archives in the year
+// folders of the historical folders.
+var Radolan GridDatabase = radolan{}
+
+// AvailableRadolanProducts contains a mapping of the RADOLAN products to the
+// available granularities.
+var AvailableRadolanProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Hourly: {dwdTypes.Grid_Radolan},
+	dwdTypes.Granularity_Daily:  {dwdTypes.Grid_Radolan},
+}
+
+// radolanProductCodes contains the codes of the composites offered in the
+// granularities.
+var radolanProductCodes = map[Granularity]string{
+	dwdTypes.Granularity_Hourly: "rw",
+	dwdTypes.Granularity_Daily:  "sf",
+}
+
+// radolanIntervals contains the intervals covered by the composites of the
+// granularities, which are stamped with the end of their interval.
+var radolanIntervals = map[Granularity]time.Duration{
+	dwdTypes.Granularity_Hourly: time.Hour,
+	dwdTypes.Granularity_Daily:  24 * time.Hour, //nolint:mnd
+}
+
+type radolan struct{}
+
+func (radolan) Name() string {
+	return RadolanUrlKey
+}
+
+func (radolan) BaseUrl() string {
+	return RadolanBaseUrl
+}
+
+func (radolan) Products() map[Granularity][]Product {
+	return AvailableRadolanProducts
+}
+
+func (db radolan) DownloadGrids(product Product, granularity Granularity, filter DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsGridProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+	code := radolanProductCodes[granularity]
+
+	productUri, err := url.JoinPath(db.BaseUrl(), granularity.UrlPart(), product.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the product folder contains the descriptions next to the period folders
+	files, _, err := readFolder(productUri)
+	if err != nil {
+		return nil, nil, err
+	}
+	descriptionFiles, err := downloadDescriptionFiles(productUri, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	var dataFiles []DataFile
+
+	download := func(uri string, period Period) {
+		group.Go(func() error {
+			filepath, err := dwd.Download(uri)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: path.Base(uri), Period: period})
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if len(filter.Periods) == 0 || slices.Contains(filter.Periods, dwdTypes.Period_Recent) {
+		uri, err := url.JoinPath(productUri, dwdTypes.Period_Recent.UrlPart(), radolanFolder_Binary)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files, _, err := readFolder(uri)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, file := range files {
+			match := radolanFileNamePattern.FindStringSubmatch(file)
+			if match == nil || !strings.EqualFold(match[1], code) {
+				continue
+			}
+
+			end, err := time.Parse(df_RadolanFileName, match[2])
+			if err != nil || !compositeOverlaps(end.Add(-radolanIntervals[granularity]), end, filter) {
+				continue
+			}
+
+			fileUri, err := url.JoinPath(uri, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			download(fileUri, dwdTypes.Period_Recent)
+		}
+	}
+
+	if len(filter.Periods) == 0 || slices.Contains(filter.Periods, dwdTypes.Period_Historical) {
+		uri, err := url.JoinPath(productUri, dwdTypes.Period_Historical.UrlPart(), radolanFolder_Binary)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, folders, err := readFolder(uri)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, folder := range folders {
+			year, isYearFolder := parseYearFolder(folder)
+			if !isYearFolder || !yearOverlaps(year, filter.Start, filter.End) {
+				continue
+			}
+
+			folderUri, err := url.JoinPath(uri, folder)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			files, _, err := readFolder(folderUri)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for _, file := range files {
+				match := radolanArchiveNamePattern.FindStringSubmatch(file)
+				if match == nil || !strings.EqualFold(match[1], code) {
+					continue
+				}
+
+				month, err := time.Parse(df_RadolanArchiveName, match[2])
+				if err != nil || !fileOverlaps(month, month.AddDate(0, 1, 0), filter) {
+					continue
+				}
+
+				fileUri, err := url.JoinPath(folderUri, file)
+				if err != nil {
+					return nil, nil, err
+				}
+				download(fileUri, dwdTypes.Period_Historical)
+			}
+		}
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, descriptionFiles)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+func (radolan) OpenGrid(file DataFile, _ Product, _ Granularity, area GridArea, labels []string) (*Archive, error) { //nolint:lll
+	archive, err := parser.OpenRadolanArchive(file.Path, area, labels)
+	if err != nil {
+		return nil, err
+	}
+	archive.Period = file.Period
+	return archive, nil
+}
+
+// compositeOverlaps reports if a single composite covering the range between
+// first and last contains datapoints requested by the filter.
+// Unlike fileOverlaps, the range is not widened, since the composites
+// cover exactly the interval stamped into their names.
+func compositeOverlaps(first, last time.Time, filter DownloadFilter) bool {
+	if !filter.End.IsZero() && first.After(filter.End) {
+		return false
+	}
+	if !filter.Start.IsZero() && last.Before(filter.Start) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package v2
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/twpayne/go-geom"
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	RegionalAveragesUrlKey  = "regionalAverages"
+	RegionalAveragesBaseUrl = "https://opendata.dwd.de/climate_environment/CDC/regional_averages_DE/"
+)
+
+// regionalAverageGermany is the id of the region covering Germany.
+const regionalAverageGermany = "Deutschland"
+
+// RegionalAverages contains the monthly, seasonal and annual area averages of
+// the federal states, some combinations of them and Germany.
+// The regions are offered as stations, which are identified by the names of
+// the regions (e.g. Bayern or Brandenburg-Berlin).
+// Each file contains the averages of all regions for a single month, season
+// or the year, so that the monthly and seasonal timeseries are merged from
+// several files.
+var RegionalAverages Database = regionalAverages{}
+
+// AvailableRegionalAveragesProducts contains a mapping of the averaged
+// products to the available granularities.
+var AvailableRegionalAveragesProducts = map[Granularity][]Product{
+	dwdTypes.Granularity_Monthly: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.RegionalAverage_SunshineDuration,
+	},
+	dwdTypes.Granularity_Seasonal: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.RegionalAverage_SunshineDuration,
+	},
+	dwdTypes.Granularity_Annual: {
+		dwdTypes.AirTemperatureMean,
+		dwdTypes.ClimateObservation_Precipitation,
+		dwdTypes.RegionalAverage_SunshineDuration,
+	},
+}
+
+// regionalAverageValues describes the averages of the products, since the
+// files contain no metadata.
+var regionalAverageValues = map[Product]parser.GridValue{
+	dwdTypes.AirTemperatureMean: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.AirTemperatureMean.String(),
+			Description: "Gebietsmittel der Lufttemperatur in 2 m Höhe",
+			Unit:        "°C",
+		},
+		Factor: 1,
+	},
+	dwdTypes.ClimateObservation_Precipitation: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.ClimateObservation_Precipitation.String(),
+			Description: "Gebietsmittel der Niederschlagshöhe",
+			Unit:        "mm",
+		},
+		Factor: 1,
+	},
+	dwdTypes.RegionalAverage_SunshineDuration: {
+		Metadata: v2.FieldMetadata{
+			Name:        dwdTypes.RegionalAverage_SunshineDuration.String(),
+			Description: "Gebietsmittel der Sonnenscheindauer",
+			Unit:        "h",
+		},
+		Factor: 1,
+	},
+}
+
+// regionLocations contains the approximate centers of the regions as
+// longitude and latitude, since the regions are published without a
+// geometry.
+// Unknown regions are located at the center of Germany.
+var regionLocations = map[string][2]float64{
+	"Baden-Wuerttemberg":           {9.00, 48.54},
+	"Bayern":                       {11.43, 48.95},
+	"Brandenburg":                  {13.40, 52.46},
+	"Brandenburg-Berlin":           {13.40, 52.47},
+	regionalAverageGermany:         {10.45, 51.16},
+	"Hessen":                       {9.03, 50.61},
+	"Mecklenburg-Vorpommern":       {12.62, 53.77},
+	"Niedersachsen":                {9.17, 52.77},
+	"Niedersachsen-Hamburg-Bremen": {9.20, 52.80},
+	"Nordrhein-Westfalen":          {7.56, 51.48},
+	"Rheinland-Pfalz":              {7.45, 49.91},
+	"Saarland":                     {6.95, 49.38},
+	"Sachsen":                      {13.35, 50.93},
+	"Sachsen-Anhalt":               {11.70, 51.97},
+	"Schleswig-Holstein":           {9.82, 54.19},
+	"Thueringen":                   {11.03, 50.90},
+	"Thueringen-Sachsen-Anhalt":    {11.40, 51.45},
+}
+
+type regionalAverages struct{}
+
+func (regionalAverages) Name() string {
+	return RegionalAveragesUrlKey
+}
+
+func (regionalAverages) BaseUrl() string {
+	return RegionalAveragesBaseUrl
+}
+
+func (regionalAverages) Products() map[Granularity][]Product {
+	return AvailableRegionalAveragesProducts
+}
+
+// DiscoverStations reads the regions contained in the files of the product
+// and returns them as stations located at the centers of the regions.
+func (db regionalAverages) DiscoverStations(granularity Granularity, product Product) ([]v2.Station, error) {
+	dataFiles, descriptionFiles, err := db.DownloadFiles("", product, granularity, DownloadFilter{})
+	if err != nil {
+		return nil, err
+	}
+	defer ReleaseFiles(dataFiles, descriptionFiles)
+
+	var group errgroup.Group
+	var l sync.Mutex
+	var names []string
+	availability := make(map[string]v2.DateTimeRange)
+
+	for _, file := range dataFiles {
+		group.Go(func() error {
+			regions, available, err := parser.ReadRegionalAverageRegions(file.Path, file.Name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file.Name, err)
+			}
+
+			l.Lock()
+			defer l.Unlock()
+			for _, region := range regions {
+				known, found := availability[region]
+				if !found {
+					names = append(names, region)
+				}
+				if found && known.Start.Before(available.Start) {
+					available.Start = known.Start
+				}
+				if found && known.End.After(available.End) {
+					available.End = known.End
+				}
+				availability[region] = available
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	stations := make([]v2.Station, len(names))
+	for idx, name := range names {
+		id := parser.RegionID(name)
+		coordinates, found := regionLocations[id]
+		if !found {
+			coordinates = regionLocations[regionalAverageGermany]
+		}
+		location := geom.NewPointFlat(geom.XYZ, []float64{coordinates[0], coordinates[1], 0})
+		location.SetSRID(stationCoordinateSRID)
+
+		stations[idx] = v2.Station{
+			ID:       id,
+			Name:     name,
+			Location: location,
+			SupportedProducts: map[dwdTypes.Product]map[dwdTypes.Granularity]v2.DateTimeRange{
+				product: {
+					granularity: availability[name],
+				},
+			},
+		}
+	}
+
+	return stations, nil
+}
+
+// DownloadFiles downloads the files of the product, which contain the
+// averages of all regions.
+// The files are not split into periods and cover all years, so the filter is
+// ignored.
+func (db regionalAverages) DownloadFiles(_ string, product Product, granularity Granularity, _ DownloadFilter) ([]DataFile, [][2]string, error) { //nolint:lll
+	if !SupportsProduct(db, granularity, product) {
+		return nil, nil, errUnsupportedProduct
+	}
+
+	productUri, err := url.JoinPath(db.BaseUrl(), granularity.UrlPart(), product.UrlPart())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, _, err := readFolder(productUri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	var dataFiles []DataFile
+	var descriptions []string
+
+	for _, file := range files {
+		// the product folders contain the descriptions next to the files
+		if _, err := parser.RegionalAverageInterval(file, 0); err != nil {
+			descriptions = append(descriptions, file)
+			continue
+		}
+
+		fileUri, err := url.JoinPath(productUri, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		group.Go(func() error {
+			filepath, err := dwd.Download(fileUri)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			dataFiles = append(dataFiles, DataFile{Path: filepath, Name: file})
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		ReleaseFiles(dataFiles, nil)
+		return nil, nil, err
+	}
+
+	descriptionFiles, err := downloadDescriptionFiles(productUri, descriptions)
+	if err != nil {
+		ReleaseFiles(dataFiles, nil)
+		return nil, nil, err
+	}
+
+	return dataFiles, descriptionFiles, nil
+}
+
+func (regionalAverages) OpenDataFile(file DataFile, stationID string, product Product, _ Granularity, labels []string) (*Archive, error) { //nolint:lll
+	return parser.OpenRegionalAverages(file.Path, file.Name, stationID, regionalAverageValues[product], labels)
+}
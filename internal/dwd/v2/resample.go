@@ -0,0 +1,190 @@
+package v2
+
+import (
+	"errors"
+	"iter"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+// missingValue is used by the DWD in the data files to mark missing values.
+const missingValue = -999
+
+var (
+	ErrResampleNotCoarser     = errors.New("the target granularity is not coarser than the source granularity")
+	ErrResampleUnsupported    = errors.New("resampling into the target granularity is not supported")
+	ErrResampleNoAggregation  = errors.New("no aggregation selected for label")
+	ErrResampleUnorderedInput = errors.New("datapoints are not ordered by their timestamps")
+)
+
+// ResampleOptions configures the temporal aggregation of a timeseries.
+type ResampleOptions struct {
+	// Target is the granularity of the resampled timeseries.
+	Target Granularity
+
+	// Aggregations contains the aggregation used for the values of each label.
+	// Labels without an aggregation use the DefaultAggregation.
+	Aggregations map[string]dwdTypes.Aggregation
+
+	// DefaultAggregation is used for all labels without an explicit
+	// aggregation.
+	DefaultAggregation dwdTypes.Aggregation
+}
+
+// Validate checks if the timeseries in the source granularity can be
+// resampled with the options.
+func (o ResampleOptions) Validate(source Granularity) error {
+	switch o.Target {
+	case dwdTypes.Granularity_None, dwdTypes.Granularity_SubDaily, dwdTypes.Granularity_MultiAnnual:
+		return ErrResampleUnsupported
+	}
+
+	if !o.Target.CoarserThan(source) {
+		return ErrResampleNotCoarser
+	}
+
+	return nil
+}
+
+// bucket collects the values of a label in a single interval of the target
+// granularity.
+type bucket struct {
+	aggregation dwdTypes.Aggregation
+	unit        *string
+	count       int
+	sum         float64
+	min         float64
+	max         float64
+	first       float64
+	last        float64
+}
+
+func (b *bucket) add(value float64) {
+	if b.count == 0 {
+		b.min, b.max, b.first = value, value, value
+	}
+	b.count++
+	b.sum += value
+	b.min = min(b.min, value)
+	b.max = max(b.max, value)
+	b.last = value
+}
+
+func (b *bucket) value() any {
+	if b.aggregation == dwdTypes.Aggregation_Count {
+		return float64(b.count)
+	}
+
+	if b.count == 0 {
+		return nil
+	}
+
+	switch b.aggregation {
+	case dwdTypes.Aggregation_Mean:
+		return b.sum / float64(b.count)
+	case dwdTypes.Aggregation_Min:
+		return b.min
+	case dwdTypes.Aggregation_Max:
+		return b.max
+	case dwdTypes.Aggregation_Sum:
+		return b.sum
+	case dwdTypes.Aggregation_First:
+		return b.first
+	case dwdTypes.Aggregation_Last:
+		return b.last
+	default:
+		return nil
+	}
+}
+
+// Resample aggregates the datapoints into the intervals of the target
+// granularity.
+// The datapoints need to be ordered by their timestamps, which allows
+// aggregating the intervals one after another without holding more than a
+// single interval in memory.
+// Missing values (including the DWD's -999 marker) and values which are not
+// numeric are excluded from the aggregations.
+// Values of a lower quality should be removed beforehand using FilterQuality.
+// The resampled datapoints are stamped with the start of their interval and
+// carry no quality level.
+func Resample(datapoints iter.Seq2[v2.Datapoint, error], options ResampleOptions) iter.Seq2[v2.Datapoint, error] {
+	return func(yield func(v2.Datapoint, error) bool) {
+		var intervalStart time.Time
+		var labels []string
+		buckets := make(map[string]*bucket)
+
+		emit := func() bool {
+			for _, label := range labels {
+				b := buckets[label]
+				dp := v2.Datapoint{
+					Label:     label,
+					Timestamp: intervalStart,
+					Value:     b.value(),
+					Unit:      b.unit,
+				}
+				if b.aggregation == dwdTypes.Aggregation_Count {
+					dp.Unit = nil
+				}
+
+				if !yield(dp, nil) {
+					return false
+				}
+			}
+
+			labels = labels[:0]
+			clear(buckets)
+			return true
+		}
+
+		for dp, err := range datapoints {
+			if err != nil {
+				yield(dp, err)
+				return
+			}
+
+			start := options.Target.Truncate(dp.Timestamp)
+			if !start.Equal(intervalStart) {
+				if start.Before(intervalStart) {
+					yield(v2.Datapoint{}, ErrResampleUnorderedInput)
+					return
+				}
+
+				if !emit() {
+					return
+				}
+				intervalStart = start
+			}
+
+			b, known := buckets[dp.Label]
+			if !known {
+				aggregation, found := options.Aggregations[dp.Label]
+				if !found {
+					aggregation = options.DefaultAggregation
+				}
+				if aggregation == dwdTypes.Aggregation_None {
+					yield(v2.Datapoint{}, ErrResampleNoAggregation)
+					return
+				}
+
+				b = &bucket{aggregation: aggregation}
+				buckets[dp.Label] = b
+				labels = append(labels, dp.Label)
+			}
+
+			if b.unit == nil {
+				b.unit = dp.Unit
+			}
+
+			value, numeric := dp.Value.(float64)
+			if !numeric || value == missingValue {
+				continue
+			}
+
+			b.add(value)
+		}
+
+		emit()
+	}
+}
@@ -0,0 +1,299 @@
+package v2
+
+import (
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+// sequence yields the datapoints.
+func sequence(datapoints ...v2.Datapoint) iter.Seq2[v2.Datapoint, error] {
+	return func(yield func(v2.Datapoint, error) bool) {
+		for _, dp := range datapoints {
+			if !yield(dp, nil) {
+				return
+			}
+		}
+	}
+}
+
+// collect reads all datapoints from the sequence and fails the test on the
+// first error.
+func collect(t *testing.T, datapoints iter.Seq2[v2.Datapoint, error]) []v2.Datapoint {
+	t.Helper()
+
+	var collected []v2.Datapoint
+	for dp, err := range datapoints {
+		if err != nil {
+			t.Fatal(err)
+		}
+		collected = append(collected, dp)
+	}
+	return collected
+}
+
+// collectError reads the datapoints from the sequence until the first error.
+func collectError(datapoints iter.Seq2[v2.Datapoint, error]) ([]v2.Datapoint, error) {
+	var collected []v2.Datapoint
+	for dp, err := range datapoints {
+		if err != nil {
+			return collected, err
+		}
+		collected = append(collected, dp)
+	}
+	return collected, nil
+}
+
+func TestResampleValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Granularity
+		source Granularity
+		err    error
+	}{
+		{name: "hourly to daily", target: dwdTypes.Granularity_Daily, source: dwdTypes.Granularity_Hourly},
+		{name: "daily to annual", target: dwdTypes.Granularity_Annual, source: dwdTypes.Granularity_Daily},
+		{name: "sub-daily target", target: dwdTypes.Granularity_SubDaily, source: dwdTypes.Granularity_Hourly, err: ErrResampleUnsupported},            //nolint:lll
+		{name: "multi-annual target", target: dwdTypes.Granularity_MultiAnnual, source: dwdTypes.Granularity_Annual, err: ErrResampleUnsupported},      //nolint:lll
+		{name: "no target", target: dwdTypes.Granularity_None, source: dwdTypes.Granularity_Daily, err: ErrResampleUnsupported},                        //nolint:lll
+		{name: "same granularity", target: dwdTypes.Granularity_Daily, source: dwdTypes.Granularity_Daily, err: ErrResampleNotCoarser},                 //nolint:lll
+		{name: "finer granularity", target: dwdTypes.Granularity_Hourly, source: dwdTypes.Granularity_Daily, err: ErrResampleNotCoarser},               //nolint:lll
+		{name: "monthly to seasonal", target: dwdTypes.Granularity_Seasonal, source: dwdTypes.Granularity_Monthly},                                     //nolint:lll
+		{name: "every ten minutes to hourly", target: dwdTypes.Granularity_Hourly, source: dwdTypes.Granularity_Every10Mins},                           //nolint:lll
+		{name: "seasonal to monthly", target: dwdTypes.Granularity_Monthly, source: dwdTypes.Granularity_Seasonal, err: ErrResampleNotCoarser},         //nolint:lll
+		{name: "every minute to every five minutes", target: dwdTypes.Granularity_Every5Mins, source: dwdTypes.Granularity_EveryMinute},                //nolint:lll
+		{name: "daily to every five minutes", target: dwdTypes.Granularity_Every5Mins, source: dwdTypes.Granularity_Daily, err: ErrResampleNotCoarser}, //nolint:lll
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResampleOptions{Target: tt.target}.Validate(tt.source)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestResampleAggregations(t *testing.T) {
+	unit := "mm"
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	datapoints := []v2.Datapoint{
+		{Label: "RS", Timestamp: day, Value: 1.0, Unit: &unit},
+		{Label: "RS", Timestamp: day.Add(time.Hour), Value: 4.0, Unit: &unit},
+		{Label: "RS", Timestamp: day.Add(2 * time.Hour), Value: 2.0, Unit: &unit},
+	}
+
+	tests := []struct {
+		aggregation dwdTypes.Aggregation
+		value       float64
+		unit        bool
+	}{
+		{aggregation: dwdTypes.Aggregation_Mean, value: 7.0 / 3, unit: true},
+		{aggregation: dwdTypes.Aggregation_Min, value: 1, unit: true},
+		{aggregation: dwdTypes.Aggregation_Max, value: 4, unit: true},
+		{aggregation: dwdTypes.Aggregation_Sum, value: 7, unit: true},
+		{aggregation: dwdTypes.Aggregation_First, value: 1, unit: true},
+		{aggregation: dwdTypes.Aggregation_Last, value: 2, unit: true},
+		{aggregation: dwdTypes.Aggregation_Count, value: 3, unit: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.aggregation.String(), func(t *testing.T) {
+			resampled := collect(t, Resample(sequence(datapoints...), ResampleOptions{
+				Target:             dwdTypes.Granularity_Daily,
+				DefaultAggregation: tt.aggregation,
+			}))
+
+			if len(resampled) != 1 {
+				t.Fatalf("expected a single datapoint, got %v", resampled)
+			}
+			dp := resampled[0]
+			if !dp.Timestamp.Equal(day) {
+				t.Errorf("expected timestamp %s, got %s", day, dp.Timestamp)
+			}
+			if dp.Value != tt.value {
+				t.Errorf("expected value %v, got %v", tt.value, dp.Value)
+			}
+			if (dp.Unit != nil) != tt.unit {
+				t.Errorf("expected unit to be set: %t, got %v", tt.unit, dp.Unit)
+			}
+		})
+	}
+}
+
+func TestResamplePerLabelAggregations(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	datapoints := []v2.Datapoint{
+		{Label: "TT_TU", Timestamp: day, Value: 2.0},
+		{Label: "RS", Timestamp: day, Value: 1.0},
+		{Label: "TT_TU", Timestamp: day.Add(time.Hour), Value: 4.0},
+		{Label: "RS", Timestamp: day.Add(time.Hour), Value: 3.0},
+	}
+
+	resampled := collect(t, Resample(sequence(datapoints...), ResampleOptions{
+		Target:             dwdTypes.Granularity_Daily,
+		Aggregations:       map[string]dwdTypes.Aggregation{"RS": dwdTypes.Aggregation_Sum},
+		DefaultAggregation: dwdTypes.Aggregation_Mean,
+	}))
+
+	expected := map[string]float64{"TT_TU": 3, "RS": 4}
+	if len(resampled) != len(expected) {
+		t.Fatalf("expected %d datapoints, got %v", len(expected), resampled)
+	}
+	// the labels are emitted in the order they first appear in the interval
+	if resampled[0].Label != "TT_TU" || resampled[1].Label != "RS" {
+		t.Errorf("unexpected label order %s, %s", resampled[0].Label, resampled[1].Label)
+	}
+	for _, dp := range resampled {
+		if dp.Value != expected[dp.Label] {
+			t.Errorf("%s: expected %v, got %v", dp.Label, expected[dp.Label], dp.Value)
+		}
+	}
+
+	_, err := collectError(Resample(sequence(datapoints...), ResampleOptions{
+		Target:       dwdTypes.Granularity_Daily,
+		Aggregations: map[string]dwdTypes.Aggregation{"RS": dwdTypes.Aggregation_Sum},
+	}))
+	if !errors.Is(err, ErrResampleNoAggregation) {
+		t.Errorf("expected %v for a label without aggregation, got %v", ErrResampleNoAggregation, err)
+	}
+}
+
+func TestResampleExcludesMissingValues(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		values []any
+		mean   any
+		count  float64
+	}{
+		{name: "nil values", values: []any{nil, 2.0, nil, 4.0}, mean: 3.0, count: 2},
+		{name: "missing value marker", values: []any{-999.0, 2.0, 4.0}, mean: 3.0, count: 2},
+		{name: "non-numeric values", values: []any{"2", 2.0, "winter", 4.0}, mean: 3.0, count: 2},
+		{name: "only missing values", values: []any{nil, -999.0, "x"}, mean: nil, count: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var datapoints []v2.Datapoint
+			for idx, value := range tt.values {
+				timestamp := day.Add(time.Duration(idx) * time.Hour)
+				datapoints = append(datapoints,
+					v2.Datapoint{Label: "mean", Timestamp: timestamp, Value: value},
+					v2.Datapoint{Label: "count", Timestamp: timestamp, Value: value},
+				)
+			}
+
+			resampled := collect(t, Resample(sequence(datapoints...), ResampleOptions{
+				Target:             dwdTypes.Granularity_Daily,
+				Aggregations:       map[string]dwdTypes.Aggregation{"count": dwdTypes.Aggregation_Count},
+				DefaultAggregation: dwdTypes.Aggregation_Mean,
+			}))
+
+			if len(resampled) != 2 {
+				t.Fatalf("expected two datapoints, got %v", resampled)
+			}
+			if resampled[0].Value != tt.mean {
+				t.Errorf("expected mean %v, got %v", tt.mean, resampled[0].Value)
+			}
+			if resampled[1].Value != tt.count {
+				t.Errorf("expected count %v, got %v", tt.count, resampled[1].Value)
+			}
+		})
+	}
+}
+
+func TestResampleTruncatesInUTC(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name       string
+		target     Granularity
+		timestamps []time.Time
+		starts     []time.Time
+	}{
+		{
+			name:   "daily across midnight in a local time zone",
+			target: dwdTypes.Granularity_Daily,
+			// 00:30 CET on the second of January is 23:30 UTC on the first
+			timestamps: []time.Time{
+				time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 2, 0, 30, 0, 0, berlin),
+				time.Date(2024, 1, 2, 1, 30, 0, 0, berlin),
+			},
+			starts: []time.Time{
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:   "meteorological winter starts in December",
+			target: dwdTypes.Granularity_Seasonal,
+			timestamps: []time.Time{
+				time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+			starts: []time.Time{
+				time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:   "every ten minutes",
+			target: dwdTypes.Granularity_Every10Mins,
+			timestamps: []time.Time{
+				time.Date(2024, 1, 1, 0, 9, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 0, 19, 0, 0, time.UTC),
+			},
+			starts: []time.Time{
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var datapoints []v2.Datapoint
+			for _, timestamp := range tt.timestamps {
+				datapoints = append(datapoints, v2.Datapoint{Label: "value", Timestamp: timestamp, Value: 1.0})
+			}
+
+			resampled := collect(t, Resample(sequence(datapoints...), ResampleOptions{
+				Target:             tt.target,
+				DefaultAggregation: dwdTypes.Aggregation_Count,
+			}))
+
+			if len(resampled) != len(tt.starts) {
+				t.Fatalf("expected %d intervals, got %v", len(tt.starts), resampled)
+			}
+			for idx, start := range tt.starts {
+				if !resampled[idx].Timestamp.Equal(start) || resampled[idx].Timestamp.Location() != time.UTC {
+					t.Errorf("interval %d: expected start %s, got %s", idx, start, resampled[idx].Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestResampleRejectsUnorderedInput(t *testing.T) {
+	day := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	_, err := collectError(Resample(sequence(
+		v2.Datapoint{Label: "value", Timestamp: day, Value: 1.0},
+		v2.Datapoint{Label: "value", Timestamp: day.AddDate(0, 0, -1), Value: 1.0},
+	), ResampleOptions{Target: dwdTypes.Granularity_Daily, DefaultAggregation: dwdTypes.Aggregation_Sum}))
+
+	if !errors.Is(err, ErrResampleUnorderedInput) {
+		t.Errorf("expected %v, got %v", ErrResampleUnorderedInput, err)
+	}
+}
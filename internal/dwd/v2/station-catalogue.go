@@ -0,0 +1,266 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/twpayne/go-geom"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"microservice/internal"
+	"microservice/internal/redis"
+	v2 "microservice/types/v2"
+)
+
+// redisKeyPattern_StationCatalogue is used to generate the redis key under
+// which the stations of a database/granularity/product combination are stored.
+const redisKeyPattern_StationCatalogue = "dwd-v2-stations:%s:%s:%s"
+
+// stationCoordinateSRID is the SRID used for the station locations.
+const stationCoordinateSRID = 4326
+
+// catalogueRefreshConcurrency limits the number of products which are crawled
+// in parallel while refreshing the station catalogue.
+const catalogueRefreshConcurrency = 4
+
+// catalogueRequests deduplicates concurrent crawls of the same product.
+var catalogueRequests singleflight.Group
+
+// cachedStation is the representation of a station in the station catalogue.
+// It is used since [v2.Station] marshals itself into a GeoJSON feature.
+type cachedStation struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Longitude float64          `json:"longitude"`
+	Latitude  float64          `json:"latitude"`
+	Height    float64          `json:"height"`
+	Available v2.DateTimeRange `json:"available"`
+}
+
+// CachedStations returns the stations available for the product in the
+// granularity from the station catalogue.
+// If the catalogue does not contain the combination yet, the stations are
+// discovered and stored in the catalogue.
+func CachedStations(ctx context.Context, database string, granularity Granularity, product Product) ([]v2.Station, error) { //nolint:lll
+	stations, catalogued, err := catalogueStations(ctx, database, granularity, product)
+	if err != nil {
+		return nil, err
+	}
+	if !catalogued {
+		return RefreshStations(ctx, database, granularity, product)
+	}
+	return stations, nil
+}
+
+// catalogueStations reads the stations available for the product in the
+// granularity from the station catalogue without discovering them.
+// If the catalogue does not contain the combination, catalogued is false.
+func catalogueStations(ctx context.Context, database string, granularity Granularity, product Product) (stations []v2.Station, catalogued bool, err error) { //nolint:lll
+	payload, err := redis.Client().Get(ctx, stationCatalogueKey(database, granularity, product)).Bytes()
+	if err != nil {
+		if redis.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var entries []cachedStation
+	if err := json.NewDecoder(brotli.NewReader(bytes.NewReader(payload))).Decode(&entries); err != nil {
+		return nil, false, err
+	}
+
+	return stationsFromCatalogue(entries, granularity, product), true, nil
+}
+
+// RefreshStations discovers the stations available for the product in the
+// granularity and replaces the matching entry in the station catalogue.
+func RefreshStations(ctx context.Context, database string, granularity Granularity, product Product) ([]v2.Station, error) { //nolint:lll
+	key := stationCatalogueKey(database, granularity, product)
+	// the crawled entries are shared between all concurrent callers, so each
+	// caller converts them into its own stations
+	sharedEntries, err, _ := catalogueRequests.Do(key, func() (any, error) {
+		db, known := LookupDatabase(database)
+		if !known {
+			return nil, errUnknownDatabase
+		}
+
+		stations, err := db.DiscoverStations(granularity, product)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]cachedStation, len(stations))
+		for idx, station := range stations {
+			entries[idx] = cachedStation{
+				ID:        station.ID,
+				Name:      station.Name,
+				Longitude: station.Location.X(),
+				Latitude:  station.Location.Y(),
+				Height:    station.Location.Z(),
+				Available: station.SupportedProducts[product][granularity],
+			}
+		}
+
+		var buf bytes.Buffer
+		writer := brotli.NewWriter(&buf)
+		if err := json.NewEncoder(writer).Encode(entries); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+
+		ttl := internal.Configuration().GetDuration(internal.ConfigKey_StationCatalogue_TTL)
+		// the entries are shared, so storing them may not depend on the
+		// request that triggered the crawl
+		if err := redis.Client().Set(context.WithoutCancel(ctx), key, buf.Bytes(), ttl).Err(); err != nil {
+			return nil, err
+		}
+
+		return entries, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stationsFromCatalogue(sharedEntries.([]cachedStation), granularity, product), nil
+}
+
+// LookupStation searches the station in the station catalogue of the products
+// offered by the database and returns it with the first product it has been
+// found for.
+// The products contained in the catalogue are searched first, and only the
+// products which have not been catalogued (or cannot be read from it) are
+// discovered afterwards.
+// If the station has not been found and discovering a product failed, the
+// errors of the discoveries are returned.
+func LookupStation(ctx context.Context, database Database, stationID string) (v2.Station, bool, error) {
+	find := func(stations []v2.Station) (v2.Station, bool) {
+		for _, candidate := range stations {
+			if candidate.ID == stationID {
+				return candidate, true
+			}
+		}
+		return v2.Station{}, false
+	}
+
+	type combination struct {
+		granularity Granularity
+		product     Product
+	}
+	var uncatalogued []combination
+	for granularity, products := range database.Products() {
+		for _, product := range products {
+			stations, catalogued, err := catalogueStations(ctx, database.Name(), granularity, product)
+			if err != nil || !catalogued {
+				uncatalogued = append(uncatalogued, combination{granularity: granularity, product: product})
+				continue
+			}
+			if station, found := find(stations); found {
+				return station, true, nil
+			}
+		}
+	}
+
+	var refreshErrors []error
+	for _, c := range uncatalogued {
+		stations, err := RefreshStations(ctx, database.Name(), c.granularity, c.product)
+		if err != nil {
+			refreshErrors = append(refreshErrors, fmt.Errorf("%s/%s/%s: %w", database.Name(), c.granularity, c.product, err))
+			continue
+		}
+		if station, found := find(stations); found {
+			return station, true, nil
+		}
+	}
+	if err := errors.Join(refreshErrors...); err != nil {
+		return v2.Station{}, false, err
+	}
+	return v2.Station{}, false, ctx.Err()
+}
+
+// RefreshStationCatalogue refreshes the station catalogue for every product
+// offered by the databases.
+func RefreshStationCatalogue(ctx context.Context) error {
+	var group errgroup.Group
+	group.SetLimit(catalogueRefreshConcurrency)
+
+	for _, db := range Databases() {
+		database := db.Name()
+		for granularity, products := range db.Products() {
+			for _, product := range products {
+				group.Go(func() error {
+					_, err := RefreshStations(ctx, database, granularity, product)
+					if err != nil {
+						return fmt.Errorf("%s/%s/%s: %w", database, granularity, product, err)
+					}
+					return nil
+				})
+			}
+		}
+	}
+
+	return group.Wait()
+}
+
+// RunStationCatalogueRefresher refreshes the station catalogue every interval
+// until the context is canceled.
+// If the interval is not positive, the catalogue is only filled on demand.
+func RunStationCatalogueRefresher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	refresh := func() {
+		start := time.Now()
+		if err := RefreshStationCatalogue(ctx); err != nil {
+			slog.Error("unable to refresh station catalogue", "error", err)
+			return
+		}
+		slog.Info("refreshed station catalogue", "duration", time.Since(start))
+	}
+
+	refresh()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// stationsFromCatalogue converts the catalogue entries back into stations.
+func stationsFromCatalogue(entries []cachedStation, granularity Granularity, product Product) []v2.Station {
+	stations := make([]v2.Station, len(entries))
+	for idx, entry := range entries {
+		location := geom.NewPointFlat(geom.XYZ, []float64{entry.Longitude, entry.Latitude, entry.Height})
+		location.SetSRID(stationCoordinateSRID)
+
+		stations[idx] = v2.Station{
+			ID:       entry.ID,
+			Name:     entry.Name,
+			Height:   entry.Height,
+			Location: location,
+			SupportedProducts: map[Product]map[Granularity]v2.DateTimeRange{
+				product: {granularity: entry.Available},
+			},
+		}
+	}
+	return stations
+}
+
+func stationCatalogueKey(database string, granularity Granularity, product Product) string {
+	return fmt.Sprintf(redisKeyPattern_StationCatalogue, database, granularity.String(), product.String())
+}
@@ -0,0 +1,334 @@
+package v2
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/twpayne/go-geom"
+	"golang.org/x/sync/errgroup"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+	dwd "microservice/internal/dwd/v2/internal"
+	"microservice/internal/dwd/v2/internal/parser"
+	v2 "microservice/types/v2"
+)
+
+const (
+	WarningsBaseUrl = "https://opendata.dwd.de/weather/alerts/cap/"
+
+	// DefaultWarningSource is used if no source has been requested.
+	DefaultWarningSource = "communeUnion"
+)
+
+const (
+	// warningSnapshot_Latest replaces the timestamp in the name of the
+	// snapshot containing the currently issued warnings.
+	warningSnapshot_Latest = "LATEST"
+
+	// df_WarningSnapshot is the format of the timestamps in the names of the
+	// snapshots.
+	df_WarningSnapshot = "20060102150405"
+
+	// warningStatus_Actual marks the warnings which are not tests or
+	// exercises.
+	warningStatus_Actual = "Actual"
+
+	// warningMessageType_Cancel marks the warnings cancelling the warnings
+	// they reference.
+	warningMessageType_Cancel = "Cancel"
+)
+
+type Severity = dwdTypes.Severity
+
+// ErrUnknownWarningSource is returned if the requested warnings are not
+// published by the DWD.
+var ErrUnknownWarningSource = errors.New("unknown warning source")
+
+// warningSnapshotPattern matches the names of the snapshots, which contain
+// the warnings issued at the time in their name, e.g.
+// Z_CAP_C_EDZW_20240110083415_PVW_STATUS_PREMIUMDWD_DISTRICT_DE.zip.
+var warningSnapshotPattern = regexp.MustCompile(`^Z_CAP_C_EDZW_(\d{14}|LATEST)_PVW_STATUS_PREMIUMDWD_([A-Z]+)_DE\.zip$`)
+
+// warningSource describes the folder containing the snapshots of warnings
+// issued for a kind of area.
+type warningSource struct {
+	// Folder is the name of the folder containing the snapshots.
+	Folder string
+
+	// Area is the kind of area contained in the names of the snapshots.
+	Area string
+}
+
+// warningSources maps the sources which may be requested to their folders.
+// The warnings of the communes are issued for unions of communes, while the
+// warnings of the districts are issued for the administrative districts.
+var warningSources = map[string]warningSource{
+	"communeUnion": {Folder: "COMMUNEUNION_DWD_STAT", Area: "COMMUNEUNION"},
+	"district":     {Folder: "DISTRICT_DWD_STAT", Area: "DISTRICT"},
+}
+
+// WarningFilter selects the warnings returned by [Warnings].
+type WarningFilter struct {
+	// Start and End limit the warnings to the ones in effect during the range.
+	// If no range is supplied, the currently issued warnings are returned.
+	Start, End time.Time
+
+	// MinimumSeverity excludes the warnings which are less severe.
+	MinimumSeverity Severity
+
+	// Point limits the warnings to the ones covering the point.
+	Point *geom.Point
+
+	// BoundingBox limits the warnings to the ones intersecting the box.
+	BoundingBox *geom.Bounds
+}
+
+// warningSnapshot is a snapshot listed in the folder of a source.
+type warningSnapshot struct {
+	uri    string
+	issued time.Time
+	latest bool
+}
+
+// Warnings returns the warnings of the source selected by the filter.
+// The DWD only keeps the snapshots of the recent days, so the warnings in
+// effect during a range are read from the snapshots issued during the range
+// and the last snapshot issued before the range.
+// Updated and cancelled warnings are replaced by their latest state.
+func Warnings(source string, filter WarningFilter) ([]v2.Warning, error) {
+	snapshots, err := listWarningSnapshots(source)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := selectWarningSnapshots(snapshots, filter.Start, filter.End)
+
+	var group errgroup.Group
+	group.SetLimit(downloadConcurrency)
+	var l sync.Mutex
+	var warnings []v2.Warning
+
+	for _, snapshot := range selected {
+		group.Go(func() error {
+			filepath, err := dwd.Download(snapshot.uri)
+			if err != nil {
+				return err
+			}
+			defer dwd.Release(filepath)
+			alerts, err := parser.ReadCapAlerts(filepath)
+			if err != nil {
+				return err
+			}
+			l.Lock()
+			warnings = append(warnings, alerts...)
+			l.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	warnings = latestWarnings(warnings)
+	return slices.DeleteFunc(warnings, func(warning v2.Warning) bool {
+		return !filter.matches(warning)
+	}), nil
+}
+
+// listWarningSnapshots lists the snapshots in the folder of the source.
+func listWarningSnapshots(source string) ([]warningSnapshot, error) {
+	warningSource, known := warningSources[source]
+	if !known {
+		return nil, ErrUnknownWarningSource
+	}
+
+	folderUri, err := url.JoinPath(WarningsBaseUrl, warningSource.Folder)
+	if err != nil {
+		return nil, err
+	}
+	files, _, err := readFolder(folderUri)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []warningSnapshot
+	for _, file := range files {
+		match := warningSnapshotPattern.FindStringSubmatch(file)
+		if match == nil || match[2] != warningSource.Area {
+			continue
+		}
+
+		fileUri, err := url.JoinPath(folderUri, file)
+		if err != nil {
+			return nil, err
+		}
+		if match[1] == warningSnapshot_Latest {
+			snapshots = append(snapshots, warningSnapshot{uri: fileUri, latest: true})
+			continue
+		}
+
+		issued, err := time.Parse(df_WarningSnapshot, match[1])
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, warningSnapshot{uri: fileUri, issued: issued})
+	}
+
+	return snapshots, nil
+}
+
+// selectWarningSnapshots selects the snapshots containing the warnings in
+// effect during the range.
+// If no range is supplied, only the latest snapshot is selected.
+func selectWarningSnapshots(snapshots []warningSnapshot, start, end time.Time) []warningSnapshot {
+	var latest, issued []warningSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.latest {
+			latest = append(latest, snapshot)
+			continue
+		}
+		issued = append(issued, snapshot)
+	}
+	sort.Slice(issued, func(i, j int) bool {
+		return issued[i].issued.Before(issued[j].issued)
+	})
+
+	if start.IsZero() && end.IsZero() {
+		if len(latest) > 0 || len(issued) == 0 {
+			return latest
+		}
+		return issued[len(issued)-1:]
+	}
+
+	var selected []warningSnapshot
+	for idx, snapshot := range issued {
+		if !end.IsZero() && snapshot.issued.After(end) {
+			break
+		}
+		// the last snapshot issued before the range contains the warnings
+		// already in effect at its start
+		if !start.IsZero() && snapshot.issued.Before(start) {
+			if idx+1 < len(issued) && !issued[idx+1].issued.After(start) {
+				continue
+			}
+		}
+		selected = append(selected, snapshot)
+	}
+	return selected
+}
+
+// latestWarnings deduplicates the warnings contained in several snapshots and
+// removes the warnings which have been updated or cancelled.
+// Cancellations and warnings which are tests or exercises are removed as well.
+func latestWarnings(warnings []v2.Warning) []v2.Warning {
+	replaced := make(map[string]bool)
+	for _, warning := range warnings {
+		for _, reference := range warning.References {
+			replaced[reference] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var latest []v2.Warning
+	for _, warning := range warnings {
+		if seen[warning.ID] || replaced[warning.ID] {
+			continue
+		}
+		seen[warning.ID] = true
+		if warning.Status != warningStatus_Actual || warning.MessageType == warningMessageType_Cancel {
+			continue
+		}
+		latest = append(latest, warning)
+	}
+
+	sort.SliceStable(latest, func(i, j int) bool {
+		return latest[i].Onset.Before(latest[j].Onset)
+	})
+	return latest
+}
+
+// matches checks if the warning is selected by the filter.
+func (f WarningFilter) matches(warning v2.Warning) bool {
+	if warning.Severity < f.MinimumSeverity {
+		return false
+	}
+	if !warning.Active(f.Start, f.End) {
+		return false
+	}
+	if f.Point != nil && !warningCovers(warning, f.Point.X(), f.Point.Y()) {
+		return false
+	}
+	if f.BoundingBox != nil && !warningIntersects(warning, f.BoundingBox) {
+		return false
+	}
+	return true
+}
+
+// warningCovers checks if one of the areas of the warning covers the point.
+func warningCovers(warning v2.Warning, x, y float64) bool {
+	for _, area := range warning.Areas {
+		for _, polygon := range area.Polygons {
+			if parser.PolygonContains(polygon, x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// warningIntersects checks if one of the areas of the warning intersects the
+// box.
+// An area intersects the box if one of its edges crosses the box or if it
+// contains the box completely.
+func warningIntersects(warning v2.Warning, box *geom.Bounds) bool {
+	for _, area := range warning.Areas {
+		for _, polygon := range area.Polygons {
+			if !polygon.Bounds().Overlaps(geom.XY, box) {
+				continue
+			}
+			if parser.PolygonContains(polygon, box.Min(0), box.Min(1)) {
+				return true
+			}
+			for idx := range polygon.NumLinearRings() {
+				coordinates := polygon.LinearRing(idx).Coords()
+				for i, j := 0, len(coordinates)-1; i < len(coordinates); j, i = i, i+1 {
+					if segmentIntersectsBox(coordinates[j], coordinates[i], box) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// segmentIntersectsBox checks if the segment between the coordinates crosses
+// the box by clipping it to the box (Liang-Barsky).
+func segmentIntersectsBox(a, b geom.Coord, box *geom.Bounds) bool {
+	lower, upper := 0.0, 1.0
+	for dim := range 2 {
+		delta := b[dim] - a[dim]
+		if delta == 0 {
+			if a[dim] < box.Min(dim) || a[dim] > box.Max(dim) {
+				return false
+			}
+			continue
+		}
+
+		t1, t2 := (box.Min(dim)-a[dim])/delta, (box.Max(dim)-a[dim])/delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		lower, upper = max(lower, t1), min(upper, t2)
+		if lower > upper {
+			return false
+		}
+	}
+	return true
+}
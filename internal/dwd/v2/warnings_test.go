@@ -0,0 +1,196 @@
+package v2
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/twpayne/go-geom"
+
+	v2 "microservice/types/v2"
+)
+
+func TestSelectWarningSnapshots(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 1, 10, hour, 0, 0, 0, time.UTC)
+	}
+	snapshots := []warningSnapshot{
+		{uri: "12", issued: at(12)},
+		{uri: "latest", latest: true},
+		{uri: "08", issued: at(8)},
+		{uri: "10", issued: at(10)},
+		{uri: "14", issued: at(14)},
+	}
+
+	tests := []struct {
+		name       string
+		snapshots  []warningSnapshot
+		start, end time.Time
+		expected   []string
+	}{
+		{name: "latest snapshot", snapshots: snapshots, expected: []string{"latest"}},
+		{
+			name:      "newest snapshot without latest snapshot",
+			snapshots: slices.DeleteFunc(slices.Clone(snapshots), func(s warningSnapshot) bool { return s.latest }),
+			expected:  []string{"14"},
+		},
+		{
+			// the snapshot issued before the start contains the warnings in
+			// effect at the start
+			name:      "range between snapshots",
+			snapshots: snapshots,
+			start:     at(11), end: at(13),
+			expected: []string{"10", "12"},
+		},
+		{
+			name:      "range starting with a snapshot",
+			snapshots: snapshots,
+			start:     at(10), end: at(12),
+			expected: []string{"10", "12"},
+		},
+		{name: "open end", snapshots: snapshots, start: at(13), expected: []string{"12", "14"}},
+		{name: "open start", snapshots: snapshots, end: at(9), expected: []string{"08"}},
+		{name: "range before all snapshots", snapshots: snapshots, start: at(1), end: at(2), expected: nil},
+		{name: "range after all snapshots", snapshots: snapshots, start: at(20), end: at(21), expected: []string{"14"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var uris []string
+			for _, snapshot := range selectWarningSnapshots(tt.snapshots, tt.start, tt.end) {
+				uris = append(uris, snapshot.uri)
+			}
+			if !slices.Equal(uris, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, uris)
+			}
+		})
+	}
+}
+
+func TestLatestWarnings(t *testing.T) {
+	onset := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	warning := func(id, messageType string, hours int, references ...string) v2.Warning {
+		return v2.Warning{
+			ID:          id,
+			Status:      warningStatus_Actual,
+			MessageType: messageType,
+			References:  references,
+			Onset:       onset.Add(time.Duration(hours) * time.Hour),
+		}
+	}
+	exercise := warning("exercise", "Alert", 0)
+	exercise.Status = "Exercise"
+
+	latest := latestWarnings([]v2.Warning{
+		warning("frost", "Alert", 2),
+		warning("wind", "Alert", 1),
+		// the update replaces the warning it references and the update is
+		// contained in several snapshots
+		warning("frost-update", "Update", 3, "frost"),
+		warning("frost-update", "Update", 3, "frost"),
+		// the cancellation removes the cancelled warning and itself
+		warning("rain", "Alert", 0),
+		warning("rain-cancel", "Cancel", 0, "rain"),
+		exercise,
+	})
+
+	var ids []string
+	for _, w := range latest {
+		ids = append(ids, w.ID)
+	}
+	if expected := []string{"wind", "frost-update"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v ordered by their onset, got %v", expected, ids)
+	}
+}
+
+// squareWarning returns a warning whose area is the square between the
+// corners.
+func squareWarning(t *testing.T, minX, minY, maxX, maxY float64) v2.Warning {
+	t.Helper()
+
+	polygon, err := geom.NewPolygon(geom.XY).SetCoords([][]geom.Coord{{
+		{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v2.Warning{Areas: []v2.WarningArea{{Polygons: []*geom.Polygon{polygon}}}}
+}
+
+func TestWarningCovers(t *testing.T) {
+	warning := squareWarning(t, 8, 53, 9, 54)
+
+	tests := []struct {
+		name   string
+		x, y   float64
+		covers bool
+	}{
+		{name: "inside", x: 8.5, y: 53.5, covers: true},
+		{name: "outside", x: 9.5, y: 53.5, covers: false},
+		{name: "below", x: 8.5, y: 52.9, covers: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warningCovers(warning, tt.x, tt.y); got != tt.covers {
+				t.Errorf("expected %t, got %t", tt.covers, got)
+			}
+		})
+	}
+
+	if warningCovers(v2.Warning{Areas: []v2.WarningArea{{}}}, 8.5, 53.5) {
+		t.Error("expected an area without polygons to cover nothing")
+	}
+}
+
+func TestSegmentIntersectsBox(t *testing.T) {
+	box := geom.NewBounds(geom.XY).Set(0, 0, 1, 1)
+
+	tests := []struct {
+		name       string
+		a, b       geom.Coord
+		intersects bool
+	}{
+		{name: "crossing the box", a: geom.Coord{-1, 0.5}, b: geom.Coord{2, 0.5}, intersects: true},
+		{name: "inside the box", a: geom.Coord{0.2, 0.2}, b: geom.Coord{0.8, 0.8}, intersects: true},
+		{name: "ending inside the box", a: geom.Coord{-1, -1}, b: geom.Coord{0.5, 0.5}, intersects: true},
+		{name: "touching a corner", a: geom.Coord{-1, 0}, b: geom.Coord{1, 2}, intersects: true},
+		{name: "along an edge", a: geom.Coord{-1, 0}, b: geom.Coord{2, 0}, intersects: true},
+		{name: "vertical outside", a: geom.Coord{1.5, -1}, b: geom.Coord{1.5, 2}, intersects: false},
+		{name: "passing a corner", a: geom.Coord{-1, 0.5}, b: geom.Coord{0.5, 2}, intersects: false},
+		{name: "stopping in front of the box", a: geom.Coord{-2, 0.5}, b: geom.Coord{-0.1, 0.5}, intersects: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := segmentIntersectsBox(tt.a, tt.b, box); got != tt.intersects {
+				t.Errorf("expected %t, got %t", tt.intersects, got)
+			}
+		})
+	}
+}
+
+func TestWarningIntersects(t *testing.T) {
+	warning := squareWarning(t, 8, 53, 9, 54)
+
+	tests := []struct {
+		name       string
+		box        []float64
+		intersects bool
+	}{
+		{name: "box inside the area", box: []float64{8.2, 53.2, 8.4, 53.4}, intersects: true},
+		{name: "area inside the box", box: []float64{7, 52, 10, 55}, intersects: true},
+		{name: "overlapping edges", box: []float64{8.5, 53.5, 9.5, 54.5}, intersects: true},
+		{name: "disjoint box", box: []float64{10, 53, 11, 54}, intersects: false},
+		{name: "touching edge", box: []float64{9, 53.2, 10, 53.4}, intersects: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := geom.NewBounds(geom.XY).Set(tt.box...)
+			if got := warningIntersects(warning, box); got != tt.intersects {
+				t.Errorf("expected %t, got %t", tt.intersects, got)
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/spf13/viper"
 
 	"microservice/internal"
+	"microservice/internal/dwd"
+	dwdV2 "microservice/internal/dwd/v2"
 	"microservice/internal/redis"
 	"microservice/router"
 )
@@ -36,6 +38,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// keep the station lists used by the v1 and v2 routes up to date
+	backgroundCtx, stopBackgroundTasks := context.WithCancel(context.Background())
+	defer stopBackgroundTasks()
+	go dwd.RunStationListPrimer(backgroundCtx, configuration.GetDuration(internal.ConfigKey_StationList_RefreshInterval))
+	go dwdV2.RunStationCatalogueRefresher(backgroundCtx, configuration.GetDuration(internal.ConfigKey_StationCatalogue_RefreshInterval)) //nolint:lll
+
 	// configure your router
 	r, err := router.Configure()
 	if err != nil {
@@ -63,6 +71,7 @@ func main() {
 
 	// Block further code execution until the shutdown signal was received
 	<-shutdownSignal
+	stopBackgroundTasks()
 
 	ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
 	defer cancel()
@@ -32,7 +32,12 @@ func Configure() (*gin.Engine, error) {
 	{
 		v2.GET("/", v2Routes.ValidateConnection)
 		v2.GET("/stations", v2Routes.DiscoverAllStations)
+		v2.GET("/stations/:database", v2Routes.DiscoverAllStations)
 		v2.GET("/timeseries/:database/:product/:granularity/:stationID", v2Routes.Timeseries)
+		v2.GET("/grids/:database/:product/:granularity", v2Routes.GridTimeseries)
+		v2.POST("/grids/:database/:product/:granularity", v2Routes.GridTimeseries)
+		v2.GET("/warnings", v2Routes.Warnings)
+		v2.POST("/warnings", v2Routes.Warnings)
 	}
 
 	return r, nil
@@ -3,9 +3,11 @@ package v1
 // This file contains all constants that are used in the code to ensure they
 // stay consistent
 
+import "microservice/internal/dwd"
+
 const contentType = "application/json"
 
-const RedisKey_StationList = "dwd-station-list"
+const RedisKey_StationList = dwd.RedisKey_StationList
 
 const DWD_OpenData_Host = "https://opendata.dwd.de"
 const DWD_OpenData_Base = "/climate_environment/CDC/observations_germany/climate"
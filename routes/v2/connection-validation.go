@@ -10,15 +10,23 @@ import (
 )
 
 func ValidateConnection(c *gin.Context) {
+	databases := make(map[string]string)
+	for _, database := range dwd.Databases() {
+		databases[database.Name()] = database.BaseUrl()
+	}
+	for _, database := range dwd.GridDatabases() {
+		databases[database.Name()] = database.BaseUrl()
+	}
+
 	health := make(map[string]v2.HealthStatus)
-	for name, url := range dwd.Databases {
-		res, err := http.Get(url) //nolint:gosec // The variable urls are from our own constants
+	for name, baseUrl := range databases {
+		ok, err := reachable(baseUrl)
 		if err != nil {
 			health[name] = v2.HealthStatus{Healthy: false, Reason: err.Error()}
 			continue
 		}
 
-		if res.StatusCode != http.StatusOK {
+		if !ok {
 			health[name] = v2.HealthStatus{Healthy: false, Reason: "response code indicated not ok"}
 			continue
 		}
@@ -27,3 +35,16 @@ func ValidateConnection(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, health)
 }
+
+// reachable checks if the database at the url responds with a status
+// indicating that it is ok.
+// The body of the response is closed right away, as only the status is used.
+func reachable(baseUrl string) (bool, error) {
+	res, err := http.Get(baseUrl) //nolint:gosec // The variable urls are from our own constants
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode == http.StatusOK, nil
+}
@@ -13,27 +13,65 @@ import (
 	v2 "microservice/types/v2"
 )
 
+// databaseStation is a station together with the database offering it.
+type databaseStation struct {
+	database string
+	station  v2.Station
+}
+
+// DiscoverAllStations lists the stations of all databases.
+// If the route contains a database, only the stations of that database are
+// listed.
 func DiscoverAllStations(c *gin.Context) {
+	var parameters struct {
+		Refresh bool `form:"refresh"`
+	}
+	if err := c.ShouldBindQuery(&parameters); err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
+
+	// a refresh forces the station catalogue to be crawled again instead of
+	// waiting for the background refresh
+	readStations := dwd.CachedStations
+	if parameters.Refresh {
+		readStations = dwd.RefreshStations
+	}
+
+	databases := dwd.Databases()
+	if name := c.Param("database"); name != "" {
+		database, known := dwd.LookupDatabase(name)
+		if !known {
+			c.Abort()
+			errUnknownDatabase.Emit(c)
+			return
+		}
+		databases = []dwd.Database{database}
+	}
+
 	var paralel errgroup.Group
 	var arrayLock sync.Mutex
-	var allStations []v2.Station
-
-	for granularity, products := range dwd.AvailableClimateObservationProducts {
-		for _, product := range products {
-			paralel.Go(func() error {
-				discoveredStations, err := dwd.DiscoverStations(dwd.ClimateObservationsBaseUrl, granularity, product)
-				if err != nil {
-					return err
-				}
-
-				for _, station := range discoveredStations {
-					arrayLock.Lock()
-					allStations = append(allStations, station)
-					arrayLock.Unlock()
-				}
-				return nil
-			})
-
+	var allStations []databaseStation
+
+	for _, database := range databases {
+		for granularity, products := range database.Products() {
+			for _, product := range products {
+				paralel.Go(func() error {
+					discoveredStations, err := readStations(c, database.Name(), granularity, product)
+					if err != nil {
+						return err
+					}
+
+					for _, station := range discoveredStations {
+						arrayLock.Lock()
+						allStations = append(allStations, databaseStation{database.Name(), station})
+						arrayLock.Unlock()
+					}
+					return nil
+				})
+
+			}
 		}
 	}
 
@@ -46,8 +84,11 @@ func DiscoverAllStations(c *gin.Context) {
 
 	mergedStations := make(map[string]v2.Station)
 
-	for _, station := range allStations {
-		mapKey := fmt.Sprintf("%f|%f", station.Location.X(), station.Location.Y())
+	for _, entry := range allStations {
+		station := entry.station
+		// the station ids differ between the databases, so only the stations
+		// of the same database are merged
+		mapKey := fmt.Sprintf("%s|%f|%f", entry.database, station.Location.X(), station.Location.Y())
 
 		processedStation, alreadyProcessed := mergedStations[mapKey]
 		if !alreadyProcessed {
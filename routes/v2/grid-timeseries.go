@@ -0,0 +1,335 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+var errInvalidGridArea = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Area",
+	Detail: "The area needs to be supplied either as coordinate using the lon and lat query parameters or as GeoJSON point, polygon or multipolygon in the request body", //nolint:lll
+}
+
+var errGridAreaOutside = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Area Outside Of Grid",
+	Detail: "The supplied area is not covered by the grids of the product",
+}
+
+var errGridRangeRequired = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Timeseries Range Required",
+	Detail: "The start and end of the timeseries need to be supplied for gridded products",
+}
+
+var errGridRangeTooLarge = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Timeseries Range Too Large",
+	Detail: "The range of the timeseries exceeds the maximum for the granularity. Hourly grids may be requested for up to 31 days and daily grids for up to 366 days", //nolint:lll
+}
+
+// maxGridRanges limits the range of the timeseries extracted from the grids
+// of the granularities, since every grid in the range is downloaded and read.
+// Granularities without a limit only contain a few grids per year.
+var maxGridRanges = map[dwd.Granularity]time.Duration{
+	dwdTypes.Granularity_Hourly: 31 * 24 * time.Hour,  //nolint:mnd
+	dwdTypes.Granularity_Daily:  366 * 24 * time.Hour, //nolint:mnd
+}
+
+var (
+	errMissingCoordinate = errors.New("missing coordinate")
+	errMissingGeometry   = errors.New("missing geometry")
+)
+
+// GridTimeseries extracts the timeseries of an area from a grid database.
+// The area is either supplied as coordinate in the query parameters or as
+// GeoJSON geometry (or feature) in the request body.
+func GridTimeseries(c *gin.Context) { //nolint:maintidx
+	outputFormat := negotiateOutputFormat(c)
+	if outputFormat == "" {
+		c.Abort()
+		errUnsupportedFormat.Emit(c)
+		return
+	}
+
+	database, known := dwd.LookupGridDatabase(c.Param("database"))
+	if !known {
+		c.Abort()
+		errUnknownDatabase.Emit(c)
+		return
+	}
+
+	ok, err := reachable(database.BaseUrl())
+	if err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
+
+	if !ok {
+		c.Abort()
+		errDatabaseUnreachable.Emit(c)
+		return
+	}
+
+	product := dwd.Product(0)
+	if err := product.Parse(c.Param("product")); err != nil {
+		c.Abort()
+		errUnknownProduct.Emit(c)
+		return
+	}
+
+	granularity := dwd.Granularity(0)
+	if err := granularity.Parse(c.Param("granularity")); err != nil {
+		c.Abort()
+		errUnknownGranularity.Emit(c)
+		return
+	}
+
+	if !dwd.SupportsGridProduct(database, granularity, product) {
+		c.Abort()
+		errUnsupportedGranularity.Emit(c)
+		return
+	}
+
+	area, err := parseGridArea(c)
+	if err != nil {
+		c.Abort()
+		errInvalidGridArea.Emit(c)
+		return
+	}
+
+	selectedLabels := parseLabels(c)
+
+	periods, err := parsePeriods(c)
+	if err != nil {
+		c.Abort()
+		errInvalidPeriod.Emit(c)
+		return
+	}
+
+	resampleOptions, err := parseResampleOptions(c)
+	if err != nil {
+		c.Abort()
+		errInvalidResample.Emit(c)
+		return
+	}
+
+	if resampleOptions != nil {
+		if err := resampleOptions.Validate(granularity); err != nil {
+			c.Abort()
+			errInvalidResample.Emit(c)
+			return
+		}
+	}
+
+	var requestedRange struct {
+		Start time.Time `form:"start"`
+		End   time.Time `form:"end"`
+	}
+	if err := c.ShouldBindQuery(&requestedRange); err != nil {
+		c.Abort()
+		errTimeseriesParseError.Emit(c)
+		return
+	}
+
+	// the grids are too large to be read completely, so the range needs to
+	// be limited on both sides
+	if requestedRange.Start.IsZero() || requestedRange.End.IsZero() {
+		c.Abort()
+		errGridRangeRequired.Emit(c)
+		return
+	}
+
+	if requestedRange.Start.After(requestedRange.End) {
+		c.Abort()
+		errTimeseriesBoundaryError.Emit(c)
+		return
+	}
+
+	maxRange, limited := maxGridRanges[granularity]
+	if limited && requestedRange.End.Sub(requestedRange.Start) > maxRange {
+		c.Abort()
+		errGridRangeTooLarge.Emit(c)
+		return
+	}
+
+	dataFiles, descriptionFiles, err := database.DownloadGrids(product, granularity, dwd.DownloadFilter{
+		Periods: periods,
+		Start:   requestedRange.Start,
+		End:     requestedRange.End,
+	})
+	if err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
+	// the files stay pinned in the cache until the response has been written
+	defer dwd.ReleaseFiles(dataFiles, descriptionFiles)
+
+	var series v2.Timeseries
+	series.DescriptionFiles, err = readDescriptionFiles(descriptionFiles)
+	if err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
+
+	var archives []*dwd.Archive
+	defer func() {
+		for _, archive := range archives {
+			_ = archive.Close()
+		}
+	}()
+
+	var labels []string
+	sequences := make([]iter.Seq2[v2.Datapoint, error], 0, len(dataFiles))
+
+	for _, dataFile := range dataFiles {
+		archive, err := database.OpenGrid(dataFile, product, granularity, area, selectedLabels)
+		if errors.Is(err, dwd.ErrAreaOutsideGrid) {
+			c.Abort()
+			errGridAreaOutside.Emit(c)
+			return
+		}
+		if err != nil {
+			c.Abort()
+			_ = c.Error(err)
+			return
+		}
+		archives = append(archives, archive)
+
+		series.Metadata = mergeMetadata(series.Metadata, archive.Metadata)
+		for _, label := range archive.Labels {
+			if !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+		sequences = append(sequences, archive.Datapoints())
+	}
+
+	for _, label := range selectedLabels {
+		if !slices.Contains(labels, label) {
+			c.Abort()
+			errUnknownLabel.Emit(c)
+			return
+		}
+	}
+
+	datapoints := dwd.DeduplicateDatapoints(dwd.MergeDatapoints(sequences...))
+	datapoints = dwd.FilterTimeRange(datapoints, requestedRange.Start, requestedRange.End)
+
+	if resampleOptions != nil {
+		datapoints = dwd.Resample(datapoints, *resampleOptions)
+	}
+
+	output := timeseriesOutput{
+		Series:     series,
+		Labels:     labels,
+		Datapoints: datapoints,
+	}
+
+	if err := writeTimeseries(c, outputFormat, output); err != nil {
+		c.Abort()
+		if !c.Writer.Written() {
+			_ = c.Error(err)
+			return
+		}
+		slog.Error("unable to stream timeseries", "error", err)
+	}
+}
+
+// parseGridArea reads the area from the lon and lat query parameters or, if
+// no coordinate has been supplied, from the GeoJSON geometry or feature in the
+// request body.
+func parseGridArea(c *gin.Context) (dwd.GridArea, error) {
+	point, err := parseCoordinate(c)
+	if err == nil {
+		return dwd.NewGridArea(point)
+	}
+	if !errors.Is(err, errMissingCoordinate) {
+		return dwd.GridArea{}, err
+	}
+
+	geometry, err := parseBodyGeometry(c)
+	if err != nil {
+		return dwd.GridArea{}, err
+	}
+
+	return dwd.NewGridArea(geometry)
+}
+
+// parseBodyGeometry reads the GeoJSON geometry or feature in the request body
+// and returns its geometry.
+// If the request has no body, errMissingGeometry is returned.
+func parseBodyGeometry(c *gin.Context) (geom.T, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errMissingGeometry
+	}
+
+	var object struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+
+	var geometry geom.T
+	if object.Type == "Feature" {
+		var feature geojson.Feature
+		if err := json.Unmarshal(body, &feature); err != nil {
+			return nil, err
+		}
+		geometry = feature.Geometry
+	} else if err := geojson.Unmarshal(body, &geometry); err != nil {
+		return nil, err
+	}
+
+	return geometry, nil
+}
+
+// parseCoordinate reads the coordinate from the lon and lat query parameters.
+func parseCoordinate(c *gin.Context) (*geom.Point, error) {
+	lon, lonSupplied := c.GetQuery("lon")
+	lat, latSupplied := c.GetQuery("lat")
+	if !lonSupplied && !latSupplied {
+		return nil, errMissingCoordinate
+	}
+
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil, err
+	}
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return geom.NewPointFlat(geom.XY, []float64{longitude, latitude}), nil
+}
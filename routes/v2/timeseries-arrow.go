@@ -0,0 +1,200 @@
+package v2
+
+import (
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/gin-gonic/gin"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+// arrowBatchSize is the number of datapoints contained in a single record
+// batch of the Arrow and Parquet outputs.
+const arrowBatchSize = 10000
+
+const (
+	arrowColumn_Timestamp = "timestamp"
+	arrowColumn_Value     = "value"
+	arrowColumn_Label     = "label"
+	arrowColumn_Unit      = "unit"
+	arrowColumn_Quality   = "quality"
+	arrowColumn_Period    = "period"
+)
+
+const (
+	arrowMetadataKey_Description = "description"
+	arrowMetadataKey_Unit        = "unit"
+	arrowMetadataKey_ValidFrom   = "validFrom"
+	arrowMetadataKey_ValidUntil  = "validUntil"
+)
+
+// arrowRecordWriter is implemented by the Arrow IPC stream writer and the
+// Parquet file writer.
+type arrowRecordWriter interface {
+	Write(rec arrow.Record) error //nolint:staticcheck
+	Close() error
+}
+
+// timeseriesSchema derives the schema of the Arrow and Parquet outputs from
+// the metadata of the timeseries.
+// The descriptions, units and validity of the labels are stored in the schema
+// metadata with the keys `<label>.<property>`.
+func timeseriesSchema(output timeseriesOutput) *arrow.Schema {
+	dictionary := &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}
+
+	var keys, values []string
+	for _, metadata := range output.Series.Metadata {
+		keys = append(keys,
+			metadata.Name+"."+arrowMetadataKey_Description,
+			metadata.Name+"."+arrowMetadataKey_Unit,
+			metadata.Name+"."+arrowMetadataKey_ValidFrom,
+			metadata.Name+"."+arrowMetadataKey_ValidUntil,
+		)
+		values = append(values,
+			metadata.Description,
+			metadata.Unit,
+			metadata.ValidFrom.Format(time.RFC3339),
+			metadata.ValidUntil.Format(time.RFC3339),
+		)
+	}
+	schemaMetadata := arrow.NewMetadata(keys, values)
+
+	return arrow.NewSchema([]arrow.Field{
+		{Name: arrowColumn_Timestamp, Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+		{Name: arrowColumn_Value, Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: arrowColumn_Label, Type: dictionary},
+		{Name: arrowColumn_Unit, Type: dictionary, Nullable: true},
+		{Name: arrowColumn_Quality, Type: dictionary, Nullable: true},
+		{Name: arrowColumn_Period, Type: dictionary, Nullable: true},
+	}, &schemaMetadata)
+}
+
+// writeArrow writes the timeseries as an Arrow IPC stream.
+func writeArrow(c *gin.Context, output timeseriesOutput) error {
+	schema := timeseriesSchema(output)
+	writer := ipc.NewWriter(c.Writer,
+		ipc.WithSchema(schema),
+		ipc.WithAllocator(memory.DefaultAllocator),
+		ipc.WithDictionaryDeltas(true),
+	)
+	return writeRecordBatches(c, schema, writer, output)
+}
+
+// writeParquet writes the timeseries as a Parquet file.
+func writeParquet(c *gin.Context, output timeseriesOutput) error {
+	schema := timeseriesSchema(output)
+	properties := parquet.NewWriterProperties(
+		parquet.WithAllocator(memory.DefaultAllocator),
+		parquet.WithCompression(compress.Codecs.Snappy),
+		parquet.WithDictionaryDefault(true),
+	)
+	writer, err := pqarrow.NewFileWriter(schema, c.Writer, properties, pqarrow.NewArrowWriterProperties(
+		pqarrow.WithStoreSchema(),
+	))
+	if err != nil {
+		return err
+	}
+	return writeRecordBatches(c, schema, writer, output)
+}
+
+// writeRecordBatches converts the datapoints into record batches and writes
+// them using the writer.
+func writeRecordBatches(c *gin.Context, schema *arrow.Schema, writer arrowRecordWriter, output timeseriesOutput) error { //nolint:lll
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	timestamps := builder.Field(0).(*array.TimestampBuilder)
+	values := builder.Field(1).(*array.Float64Builder)
+	labels := builder.Field(2).(*array.BinaryDictionaryBuilder)
+	units := builder.Field(3).(*array.BinaryDictionaryBuilder)
+	qualities := builder.Field(4).(*array.BinaryDictionaryBuilder)
+	periods := builder.Field(5).(*array.BinaryDictionaryBuilder)
+
+	rows := 0
+	flush := func() error {
+		if rows == 0 {
+			return nil
+		}
+		record := builder.NewRecord()
+		defer record.Release()
+
+		rows = 0
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	for dp, err := range output.Datapoints {
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+
+		timestamps.Append(arrow.Timestamp(dp.Timestamp.UnixMilli()))
+
+		if value, isFloat := dp.Value.(float64); isFloat {
+			values.Append(value)
+		} else {
+			values.AppendNull()
+		}
+
+		if err := labels.AppendString(dp.Label); err != nil {
+			_ = writer.Close()
+			return err
+		}
+
+		if dp.Unit != nil {
+			err = units.AppendString(*dp.Unit)
+		} else {
+			units.AppendNull()
+		}
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+
+		if dp.QualityLevel != nil {
+			err = qualities.AppendString(dp.QualityLevel.String())
+		} else {
+			qualities.AppendNull()
+		}
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+
+		if dp.Period != dwdTypes.Period_None {
+			err = periods.AppendString(dp.Period.String())
+		} else {
+			periods.AppendNull()
+		}
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+
+		rows++
+		if rows == arrowBatchSize {
+			if err := flush(); err != nil {
+				_ = writer.Close()
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		_ = writer.Close()
+		return err
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,209 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/gin-gonic/gin"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+// arrowRow contains the values of a single row of the Arrow and Parquet
+// outputs, with missing values represented by empty strings and a nil value.
+type arrowRow struct {
+	Timestamp time.Time
+	Value     *float64
+	Label     string
+	Unit      string
+	Quality   string
+	Period    string
+}
+
+// expectedArrowRows returns the rows written for sampleTimeseriesOutput.
+func expectedArrowRows() []arrowRow {
+	value := func(v float64) *float64 {
+		return &v
+	}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	finished := dwdTypes.QCP_Finished.String()
+
+	return []arrowRow{
+		{Timestamp: first, Value: value(2.5), Label: "TT_TU", Unit: "°C", Quality: finished, Period: "historical"},
+		{Timestamp: first, Value: value(91), Label: "RF_TU", Quality: finished, Period: "historical"},
+		{Timestamp: second, Value: value(-0.25), Label: "TT_TU", Unit: "°C", Period: "recent"},
+		{Timestamp: second, Label: "RF_TU", Period: "recent"},
+	}
+}
+
+// dictionaryString returns the string value of a dictionary encoded column or
+// an empty string for null values.
+func dictionaryString(column arrow.Array, row int) string {
+	if column.IsNull(row) {
+		return ""
+	}
+	switch c := column.(type) {
+	case *array.Dictionary:
+		return c.Dictionary().(*array.String).Value(c.GetValueIndex(row))
+	case *array.String:
+		return c.Value(row)
+	default:
+		return column.ValueStr(row)
+	}
+}
+
+// readArrowRows converts the records into rows.
+func readArrowRows(t *testing.T, records []arrow.Record) []arrowRow { //nolint:staticcheck
+	t.Helper()
+
+	var rows []arrowRow
+	for _, record := range records {
+		timestamps := record.Column(0).(*array.Timestamp)
+		values := record.Column(1).(*array.Float64)
+		for row := range int(record.NumRows()) {
+			r := arrowRow{
+				Timestamp: timestamps.Value(row).ToTime(arrow.Millisecond).UTC(),
+				Label:     dictionaryString(record.Column(2), row),
+				Unit:      dictionaryString(record.Column(3), row),
+				Quality:   dictionaryString(record.Column(4), row),
+				Period:    dictionaryString(record.Column(5), row),
+			}
+			if !values.IsNull(row) {
+				v := values.Value(row)
+				r.Value = &v
+			}
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// compareArrowRows reports the differences between the rows.
+func compareArrowRows(t *testing.T, expected, got []arrowRow) {
+	t.Helper()
+
+	if len(expected) != len(got) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(got))
+	}
+	for idx := range expected {
+		e, g := expected[idx], got[idx]
+		switch {
+		case !e.Timestamp.Equal(g.Timestamp):
+			t.Errorf("row %d: expected timestamp %s, got %s", idx, e.Timestamp, g.Timestamp)
+		case (e.Value == nil) != (g.Value == nil):
+			t.Errorf("row %d: expected value %v, got %v", idx, e.Value, g.Value)
+		case e.Value != nil && *e.Value != *g.Value:
+			t.Errorf("row %d: expected value %f, got %f", idx, *e.Value, *g.Value)
+		case e.Label != g.Label || e.Unit != g.Unit || e.Quality != g.Quality || e.Period != g.Period:
+			t.Errorf("row %d: expected %+v, got %+v", idx, e, g)
+		}
+	}
+}
+
+// checkSchemaMetadata checks the descriptions and units of the labels stored
+// in the schema metadata.
+func checkSchemaMetadata(t *testing.T, metadata arrow.Metadata) {
+	t.Helper()
+
+	for key, expected := range map[string]string{
+		"TT_TU.description": "air temperature",
+		"TT_TU.unit":        "°C",
+		"RF_TU.unit":        "%",
+		"TT_TU.validFrom":   "2024-01-01T00:00:00Z",
+		"RF_TU.validUntil":  "2024-01-01T01:00:00Z",
+	} {
+		idx := metadata.FindKey(key)
+		if idx == -1 {
+			t.Errorf("schema metadata %s missing", key)
+			continue
+		}
+		if got := metadata.Values()[idx]; got != expected {
+			t.Errorf("schema metadata %s: expected %q, got %q", key, expected, got)
+		}
+	}
+}
+
+func TestWriteArrowRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	if err := writeArrow(c, sampleTimeseriesOutput()); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(recorder.Body.Bytes()), ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Release()
+
+	checkSchemaMetadata(t, reader.Schema().Metadata())
+
+	var records []arrow.Record //nolint:staticcheck
+	for reader.Next() {
+		record := reader.Record() //nolint:staticcheck
+		record.Retain()
+		defer record.Release()
+		records = append(records, record)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	compareArrowRows(t, expectedArrowRows(), readArrowRows(t, records))
+}
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	if err := writeParquet(c, sampleTimeseriesOutput()); err != nil {
+		t.Fatal(err)
+	}
+
+	parquetReader, err := file.NewParquetReader(bytes.NewReader(recorder.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parquetReader.Close()
+
+	reader, err := pqarrow.NewFileReader(parquetReader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := reader.ReadTable(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.Release()
+
+	// the schema metadata is stored in the key-value metadata of the file
+	fileMetadata := parquetReader.MetaData().KeyValueMetadata()
+	checkSchemaMetadata(t, arrow.NewMetadata(fileMetadata.Keys(), fileMetadata.Values()))
+
+	tableReader := array.NewTableReader(table, -1)
+	defer tableReader.Release()
+
+	var records []arrow.Record //nolint:staticcheck
+	for tableReader.Next() {
+		record := tableReader.Record() //nolint:staticcheck
+		record.Retain()
+		defer record.Release()
+		records = append(records, record)
+	}
+
+	compareArrowRows(t, expectedArrowRows(), readArrowRows(t, records))
+}
@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+const (
+	csvColumn_Timestamp     = "timestamp"
+	csvColumnSuffix_Quality = "_quality"
+	csvColumnSuffix_Period  = "_period"
+	csvCommentPrefix        = "# "
+)
+
+// writeCSV writes the timeseries as a wide table.
+// Every row contains the values of a single timestamp with one column per
+// label and a quality and a period column for each of the labels.
+// The period columns contain the period folder each value has been read from,
+// as the periods of the labels may differ after merging the periods.
+// The descriptions and units of the labels are written as comments in front of
+// the header row, while the summary of the quality filter is written as
+// comments after the last row.
+func writeCSV(c *gin.Context, output timeseriesOutput) error {
+	w := bufio.NewWriter(c.Writer)
+
+	for _, metadata := range output.Series.Metadata {
+		if !slices.Contains(output.Labels, metadata.Name) {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s%s: %s [%s] (%s - %s)\n", csvCommentPrefix, metadata.Name, metadata.Description,
+			metadata.Unit, metadata.ValidFrom.Format(time.DateOnly), metadata.ValidUntil.Format(time.DateOnly))
+	}
+
+	writer := csv.NewWriter(w)
+
+	header := make([]string, 0, 1+3*len(output.Labels)) //nolint:mnd
+	header = append(header, csvColumn_Timestamp)
+	for _, label := range output.Labels {
+		header = append(header, label, label+csvColumnSuffix_Quality, label+csvColumnSuffix_Period)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(output.Labels))
+	for idx, label := range output.Labels {
+		columns[label] = 1 + 3*idx //nolint:mnd
+	}
+
+	row := make([]string, len(header))
+	var rowTimestamp time.Time
+	rowCount := 0
+
+	flushRow := func() error {
+		if rowTimestamp.IsZero() {
+			return nil
+		}
+
+		row[0] = rowTimestamp.Format(time.RFC3339)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		clear(row)
+
+		rowCount++
+		if rowCount%flushInterval == 0 {
+			writer.Flush()
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}
+
+	for dp, err := range output.Datapoints {
+		if err != nil {
+			return err
+		}
+
+		if !dp.Timestamp.Equal(rowTimestamp) {
+			if err := flushRow(); err != nil {
+				return err
+			}
+			rowTimestamp = dp.Timestamp
+		}
+
+		column, known := columns[dp.Label]
+		if !known {
+			continue
+		}
+
+		row[column] = formatCSVValue(dp.Value)
+		row[column+1] = formatCSVQuality(dp.QualityLevel)
+		row[column+2] = dp.Period.String()
+	}
+
+	if err := flushRow(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	for _, label := range slices.Sorted(maps.Keys(output.QualitySummary)) {
+		flags := output.QualitySummary[label]
+		counts := make([]string, 0, len(flags))
+		for _, flag := range slices.Sorted(maps.Keys(flags)) {
+			counts = append(counts, fmt.Sprintf("%s=%d", flag, flags[flag]))
+		}
+		_, _ = fmt.Fprintf(w, "%squality filter: %s %s\n", csvCommentPrefix, label, strings.Join(counts, " "))
+	}
+
+	return w.Flush()
+}
+
+// formatCSVValue formats the value of a datapoint for a CSV cell.
+// Missing values are represented by an empty cell.
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatCSVQuality formats the quality level of a datapoint for a CSV cell.
+func formatCSVQuality(level *dwdTypes.QualityControlProcedure) string {
+	if level == nil {
+		return ""
+	}
+	return level.String()
+}
@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	dwd "microservice/internal/dwd/v2"
+	"microservice/internal/dwd/v2/dwdTypes"
+	v2 "microservice/types/v2"
+)
+
+// sampleTimeseriesOutput returns a timeseries with two labels over two
+// timestamps, where the second label is missing a value and a quality level.
+func sampleTimeseriesOutput() timeseriesOutput {
+	unit := "°C"
+	level := dwdTypes.QCP_Finished
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	datapoints := []v2.Datapoint{
+		{Label: "TT_TU", Timestamp: first, Value: 2.5, Unit: &unit, QualityLevel: &level, Period: dwdTypes.Period_Historical},
+		{Label: "RF_TU", Timestamp: first, Value: 91.0, QualityLevel: &level, Period: dwdTypes.Period_Historical},
+		{Label: "TT_TU", Timestamp: second, Value: -0.25, Unit: &unit, Period: dwdTypes.Period_Recent},
+		{Label: "RF_TU", Timestamp: second, Value: nil, Period: dwdTypes.Period_Recent},
+	}
+
+	return timeseriesOutput{
+		Series: v2.Timeseries{
+			Metadata: []v2.FieldMetadata{
+				{Name: "TT_TU", Description: "air temperature", Unit: unit, ValidFrom: first, ValidUntil: second},
+				{Name: "RF_TU", Description: "relative humidity", Unit: "%", ValidFrom: first, ValidUntil: second},
+				{Name: "QN_9", Description: "quality level", ValidFrom: first, ValidUntil: second},
+			},
+		},
+		Labels: []string{"TT_TU", "RF_TU"},
+		Datapoints: func(yield func(v2.Datapoint, error) bool) {
+			for _, dp := range datapoints {
+				if !yield(dp, nil) {
+					return
+				}
+			}
+		},
+		QualitySummary: dwd.QualityFilterSummary{
+			"TT_TU": {"missing": 1},
+			"RF_TU": {"automatic control and correction": 2, "missing": 1},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	output := sampleTimeseriesOutput()
+	if err := writeCSV(c, output); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# TT_TU: air temperature [°C] (2024-01-01 - 2024-01-01)\n" +
+		"# RF_TU: relative humidity [%] (2024-01-01 - 2024-01-01)\n" +
+		"timestamp,TT_TU,TT_TU_quality,TT_TU_period,RF_TU,RF_TU_quality,RF_TU_period\n" +
+		"2024-01-01T00:00:00Z,2.5," + dwdTypes.QCP_Finished.String() + ",historical,91," + dwdTypes.QCP_Finished.String() + ",historical\n" + //nolint:lll
+		"2024-01-01T01:00:00Z,-0.25,,recent,,,recent\n" +
+		"# quality filter: RF_TU automatic control and correction=2 missing=1\n" +
+		"# quality filter: TT_TU missing=1\n"
+
+	if got := recorder.Body.String(); got != expected {
+		t.Errorf("unexpected output\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestWriteCSVSkipsUnknownLabels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	output := sampleTimeseriesOutput()
+	output.Labels = []string{"RF_TU"}
+	output.QualitySummary = nil
+	if err := writeCSV(c, output); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# RF_TU: relative humidity [%] (2024-01-01 - 2024-01-01)\n" +
+		"timestamp,RF_TU,RF_TU_quality,RF_TU_period\n" +
+		"2024-01-01T00:00:00Z,91," + dwdTypes.QCP_Finished.String() + ",historical\n" +
+		"2024-01-01T01:00:00Z,,,recent\n"
+
+	if got := recorder.Body.String(); got != expected {
+		t.Errorf("unexpected output\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
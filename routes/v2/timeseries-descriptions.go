@@ -0,0 +1,73 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	v2 "microservice/types/v2"
+)
+
+// readDescriptionFiles reads the downloaded description files into the
+// base64 encoded files attached to a timeseries.
+// The German and English dataset descriptions are named after their language,
+// while all other files are named after their file names.
+func readDescriptionFiles(descriptionFiles [][2]string) ([]v2.File, error) {
+	var files []v2.File
+
+	for _, descriptionFile := range descriptionFiles {
+		var file v2.File
+
+		if strings.HasPrefix(descriptionFile[0], "BESCHREIBUNG") {
+			file.Name = "[DE] Datensatzbeschreibung"
+		}
+
+		if strings.HasPrefix(descriptionFile[0], "DESCRIPTION") {
+			file.Name = "[EN] Dataset Description"
+		}
+
+		if file.Name == "" {
+			file.Name = strings.Trim(strings.SplitAfterN(descriptionFile[0], ".", 2)[0], ".") //nolint:mnd
+		}
+
+		content, mimeType, err := encodeDescriptionFile(descriptionFile[1])
+		if err != nil {
+			return nil, err
+		}
+		file.Content = content
+		file.MimeType = mimeType
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// encodeDescriptionFile detects the mime type of the file and encodes its
+// content using base64.
+func encodeDescriptionFile(path string) (content, mimeType string, err error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	mime, err := mimetype.DetectReader(f)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	enc := base64.NewEncoder(base64.StdEncoding, &buf)
+	_, _ = f.Seek(0, io.SeekStart)
+	if _, err := io.Copy(enc, f); err != nil {
+		return "", "", err
+	}
+	_ = enc.Close()
+
+	return buf.String(), mime.String(), nil
+}
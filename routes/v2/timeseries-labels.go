@@ -0,0 +1,34 @@
+package v2
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wisdom-oss/common-go/v3/types"
+)
+
+var errUnknownLabel = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Unknown Label",
+	Detail: "At least one of the selected labels is not contained in the timeseries of the product",
+}
+
+// parseLabels reads the labels selected by the labels query parameter.
+// Multiple labels may be supplied comma separated or by repeating the
+// parameter.
+// If no labels have been selected, nil is returned.
+func parseLabels(c *gin.Context) (labels []string) {
+	for _, parameter := range c.QueryArray("labels") {
+		for _, label := range strings.Split(parameter, ",") {
+			label = strings.TrimSpace(label)
+			if label == "" || slices.Contains(labels, label) {
+				continue
+			}
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
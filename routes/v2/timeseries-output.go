@@ -0,0 +1,259 @@
+package v2
+
+import (
+	"bufio"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+	v2 "microservice/types/v2"
+)
+
+const (
+	outputFormat_JSON    = "json"
+	outputFormat_NDJSON  = "ndjson"
+	outputFormat_CSV     = "csv"
+	outputFormat_Arrow   = "arrow"
+	outputFormat_Parquet = "parquet"
+)
+
+const (
+	mimeType_JSON    = "application/json"
+	mimeType_NDJSON  = "application/x-ndjson"
+	mimeType_CSV     = "text/csv"
+	mimeType_Arrow   = "application/vnd.apache.arrow.stream"
+	mimeType_Parquet = "application/vnd.apache.parquet"
+)
+
+// flushInterval is the number of datapoints after which the streamed response
+// is flushed to the client.
+const flushInterval = 1000
+
+var errUnsupportedFormat = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.7",
+	Status: http.StatusNotAcceptable,
+	Title:  "Unsupported Output Format",
+	Detail: "The requested output format is not supported. Use the format query parameter or the Accept header to select a supported format", //nolint:lll
+}
+
+// outputFormats maps the supported output formats to the content type used
+// for the responses.
+var outputFormats = map[string]string{
+	outputFormat_JSON:    mimeType_JSON,
+	outputFormat_NDJSON:  mimeType_NDJSON,
+	outputFormat_CSV:     mimeType_CSV,
+	outputFormat_Arrow:   mimeType_Arrow,
+	outputFormat_Parquet: mimeType_Parquet,
+}
+
+// timeseriesOutput contains everything needed to write a timeseries in one of
+// the output formats.
+type timeseriesOutput struct {
+	// Series contains the metadata and description files of the timeseries.
+	// The datapoints are not set in the series and are read from Datapoints
+	// instead.
+	Series v2.Timeseries
+
+	// Labels contains the labels of the parameters in the timeseries in the
+	// order they appear in the data files.
+	Labels []string
+
+	// Datapoints yields the datapoints ordered by their timestamps.
+	Datapoints iter.Seq2[v2.Datapoint, error]
+
+	// QualitySummary counts the datapoints rejected by the quality filter.
+	// It is only complete after all datapoints have been consumed and is nil
+	// if no quality filter has been requested.
+	QualitySummary dwd.QualityFilterSummary
+}
+
+// negotiateOutputFormat determines the output format of the timeseries.
+// The format query parameter takes precedence over the Accept header.
+// If no supported format was requested, an empty string is returned.
+func negotiateOutputFormat(c *gin.Context) string {
+	if format := strings.ToLower(strings.TrimSpace(c.Query("format"))); format != "" {
+		if _, supported := outputFormats[format]; !supported {
+			return ""
+		}
+		return format
+	}
+
+	accepted := c.NegotiateFormat(mimeType_JSON, mimeType_NDJSON, "application/ndjson", mimeType_CSV,
+		mimeType_Arrow, mimeType_Parquet)
+	switch accepted {
+	case mimeType_JSON:
+		return outputFormat_JSON
+	case mimeType_NDJSON, "application/ndjson":
+		return outputFormat_NDJSON
+	case mimeType_CSV:
+		return outputFormat_CSV
+	case mimeType_Arrow:
+		return outputFormat_Arrow
+	case mimeType_Parquet:
+		return outputFormat_Parquet
+	default:
+		return ""
+	}
+}
+
+// writeTimeseries streams the timeseries in the output format to the client.
+// If a quality filter has been applied, its summary is sent as a trailer after
+// the timeseries.
+func writeTimeseries(c *gin.Context, format string, output timeseriesOutput) error {
+	c.Header("Content-Type", outputFormats[format])
+	if output.QualitySummary != nil {
+		c.Header("Trailer", qualityTrailer)
+	}
+	c.Status(http.StatusOK)
+
+	var err error
+	switch format {
+	case outputFormat_NDJSON:
+		err = writeNDJSON(c, output.Datapoints)
+	case outputFormat_CSV:
+		err = writeCSV(c, output)
+	case outputFormat_Arrow:
+		err = writeArrow(c, output)
+	case outputFormat_Parquet:
+		err = writeParquet(c, output)
+	default:
+		err = writeJSON(c, output)
+	}
+	if err != nil || output.QualitySummary == nil {
+		return err
+	}
+
+	summary, err := json.Marshal(output.QualitySummary)
+	if err != nil {
+		return err
+	}
+	c.Writer.Header().Set(qualityTrailer, string(summary))
+	return nil
+}
+
+// writeJSON writes the timeseries as a single JSON object while streaming the
+// datapoints into the datapoints array of the object.
+// The summary of the quality filter is written after the datapoints.
+func writeJSON(c *gin.Context, output timeseriesOutput) error {
+	w := bufio.NewWriter(c.Writer)
+
+	metadata, err := json.Marshal(output.Series.Metadata)
+	if err != nil {
+		return err
+	}
+
+	descriptionFiles, err := json.Marshal(output.Series.DescriptionFiles)
+	if err != nil {
+		return err
+	}
+
+	_, _ = w.WriteString(`{"metadata":`)
+	_, _ = w.Write(metadata)
+	_, _ = w.WriteString(`,"descriptionFiles":`)
+	_, _ = w.Write(descriptionFiles)
+	_, _ = w.WriteString(`,"datapoints":[`)
+
+	count := 0
+	for dp, err := range output.Datapoints {
+		if err != nil {
+			return err
+		}
+
+		encodedDatapoint, err := json.Marshal(dp)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			_ = w.WriteByte(',')
+		}
+		_, _ = w.Write(encodedDatapoint)
+
+		count++
+		if count%flushInterval == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+	}
+
+	_ = w.WriteByte(']')
+
+	if output.QualitySummary != nil {
+		summary, err := json.Marshal(output.QualitySummary)
+		if err != nil {
+			return err
+		}
+		_, _ = w.WriteString(`,"qualityFilter":`)
+		_, _ = w.Write(summary)
+	}
+
+	_ = w.WriteByte('}')
+	return w.Flush()
+}
+
+// writeNDJSON writes every datapoint as a separate JSON document on its own
+// line.
+func writeNDJSON(c *gin.Context, datapoints iter.Seq2[v2.Datapoint, error]) error {
+	w := bufio.NewWriter(c.Writer)
+	encoder := json.NewEncoder(w)
+
+	count := 0
+	for dp, err := range datapoints {
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(dp); err != nil {
+			return err
+		}
+
+		count++
+		if count%flushInterval == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+	}
+
+	return w.Flush()
+}
+
+// mergeMetadata adds the metadata of an archive to the metadata of the
+// timeseries.
+// The archives of the periods describe the same labels, so the metadata is
+// merged by label and covers the ranges of all archives.
+func mergeMetadata(metadata []v2.FieldMetadata, additions []v2.FieldMetadata) []v2.FieldMetadata {
+	for _, addition := range additions {
+		idx := slices.IndexFunc(metadata, func(m v2.FieldMetadata) bool {
+			return m.Name == addition.Name
+		})
+		if idx == -1 {
+			metadata = append(metadata, addition)
+			continue
+		}
+
+		merged := &metadata[idx]
+		if merged.Description == "" {
+			merged.Description = addition.Description
+		}
+		if merged.Unit == "" {
+			merged.Unit = addition.Unit
+		}
+		if merged.ValidFrom.IsZero() || (!addition.ValidFrom.IsZero() && addition.ValidFrom.Before(merged.ValidFrom)) {
+			merged.ValidFrom = addition.ValidFrom
+		}
+		if addition.ValidUntil.After(merged.ValidUntil) {
+			merged.ValidUntil = addition.ValidUntil
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,43 @@
+package v2
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+)
+
+var errInvalidPeriod = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Period",
+	Detail: "The selected periods need to be one of historical, recent or now",
+}
+
+// parsePeriods reads the periods selected by the periods query parameter.
+// Multiple periods may be supplied comma separated or by repeating the
+// parameter.
+// If no periods have been selected, nil is returned.
+func parsePeriods(c *gin.Context) (periods []dwd.Period, err error) {
+	for _, parameter := range c.QueryArray("periods") {
+		for _, entry := range strings.Split(parameter, ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+
+			var period dwd.Period
+			if err := period.Parse(entry); err != nil {
+				return nil, err
+			}
+
+			if !slices.Contains(periods, period) {
+				periods = append(periods, period)
+			}
+		}
+	}
+	return periods, nil
+}
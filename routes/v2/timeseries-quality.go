@@ -0,0 +1,107 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+var errInvalidQualityFilter = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Quality Filter",
+	Detail: "The quality filter is invalid. Quality levels need to be either a known level name or a level number used by the DWD (1, 2, 3, 5, 7, 8, 9 or 10) and the quality action needs to be either drop or null", //nolint:lll
+}
+
+// qualityTrailer is the HTTP trailer containing the JSON encoded summary of
+// the quality filter after the timeseries has been sent.
+const qualityTrailer = "X-Quality-Filter-Summary"
+
+// qualityParameters contains the query parameters used to filter the
+// datapoints by their quality levels.
+type qualityParameters struct {
+	// ExcludedLevels contains the quality levels which are rejected.
+	// Multiple levels may be supplied comma separated or by repeating the
+	// parameter.
+	ExcludedLevels []string `form:"excludeLevels"`
+
+	// MinimumQuality rejects datapoints with a lower quality level.
+	MinimumQuality string `form:"minQuality"`
+
+	// KeepMissing keeps the datapoints without a quality level, which are
+	// rejected by MinimumQuality otherwise.
+	KeepMissing bool `form:"keepMissingQuality"`
+
+	// Action selects if the rejected datapoints are dropped or if their values
+	// are nulled.
+	Action string `form:"qualityAction"`
+}
+
+// parseQualityFilter converts the query parameters into the filter applied to
+// the quality levels of the datapoints.
+func parseQualityFilter(c *gin.Context) (dwd.QualityFilter, error) {
+	var filter dwd.QualityFilter
+
+	var parameters qualityParameters
+	if err := c.ShouldBindQuery(&parameters); err != nil {
+		return filter, err
+	}
+
+	for _, parameter := range parameters.ExcludedLevels {
+		for _, entry := range strings.Split(parameter, ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+
+			level, err := parseQualityLevel(entry)
+			if err != nil {
+				return filter, err
+			}
+			filter.ExcludedLevels = append(filter.ExcludedLevels, level)
+		}
+	}
+
+	if parameters.MinimumQuality != "" {
+		level, err := parseQualityLevel(parameters.MinimumQuality)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinimumQuality = &level
+	}
+	filter.KeepMissing = parameters.KeepMissing
+
+	if parameters.Action != "" {
+		if err := filter.Action.Parse(parameters.Action); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+var errUnknownQualityLevel = errors.New("unknown quality level")
+
+// parseQualityLevel parses a quality level from either its name or its number.
+func parseQualityLevel(s string) (dwdTypes.QualityControlProcedure, error) {
+	s = strings.TrimSpace(s)
+
+	var level dwdTypes.QualityControlProcedure
+	var err error
+	if number, convErr := strconv.Atoi(s); convErr == nil {
+		err = level.Parse(number)
+	} else {
+		err = level.Parse(s)
+	}
+
+	if err != nil {
+		return level, errUnknownQualityLevel
+	}
+	return level, nil
+}
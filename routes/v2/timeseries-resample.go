@@ -0,0 +1,83 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+var errInvalidResample = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Resampling",
+	Detail: "The resampling parameters are invalid. The target granularity needs to be coarser than the granularity of the product and the aggregations need to be one of mean, min, max, sum, count, first or last", //nolint:lll
+}
+
+// resampleParameters contains the query parameters used to configure the
+// temporal aggregation of the timeseries.
+type resampleParameters struct {
+	// Target contains the granularity the timeseries is resampled into.
+	Target string `form:"resample"`
+
+	// Aggregations contains either a single aggregation used for all labels
+	// or aggregations for single labels in the form `<label>:<aggregation>`.
+	// Multiple entries may be supplied comma separated or by repeating the
+	// parameter.
+	Aggregations []string `form:"aggregation"`
+}
+
+var errMalformedAggregation = errors.New("malformed aggregation")
+
+// parseResampleOptions converts the query parameters into the options used for
+// resampling the timeseries.
+// If no resampling has been requested, nil is returned.
+func parseResampleOptions(c *gin.Context) (*dwd.ResampleOptions, error) {
+	var parameters resampleParameters
+	if err := c.ShouldBindQuery(&parameters); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(parameters.Target) == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	options := dwd.ResampleOptions{
+		DefaultAggregation: dwdTypes.Aggregation_Mean,
+		Aggregations:       make(map[string]dwdTypes.Aggregation),
+	}
+
+	if err := options.Target.Parse(parameters.Target); err != nil {
+		return nil, err
+	}
+
+	for _, parameter := range parameters.Aggregations {
+		for _, entry := range strings.Split(parameter, ",") {
+			var aggregation dwdTypes.Aggregation
+			label, function, labelled := strings.Cut(strings.TrimSpace(entry), ":")
+			if !labelled {
+				if err := aggregation.Parse(label); err != nil {
+					return nil, err
+				}
+				options.DefaultAggregation = aggregation
+				continue
+			}
+
+			if strings.TrimSpace(label) == "" {
+				return nil, errMalformedAggregation
+			}
+
+			if err := aggregation.Parse(function); err != nil {
+				return nil, err
+			}
+			options.Aggregations[strings.TrimSpace(label)] = aggregation
+		}
+	}
+
+	return &options, nil
+}
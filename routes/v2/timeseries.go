@@ -1,16 +1,12 @@
 package v2
 
 import (
-	"bytes"
-	"encoding/base64"
-	"io"
+	"iter"
+	"log/slog"
 	"net/http"
-	"os"
 	"slices"
-	"strings"
 	"time"
 
-	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
 	"github.com/wisdom-oss/common-go/v3/types"
 
@@ -96,26 +92,28 @@ var errTimeseriesBoundaryError = types.ServiceError{
 }
 
 func Timeseries(c *gin.Context) { //nolint:maintidx
-	database := c.Param("database")
-	databaseKeys := make([]string, 0, len(dwd.Databases))
-	for k := range dwd.Databases {
-		databaseKeys = append(databaseKeys, k)
+	outputFormat := negotiateOutputFormat(c)
+	if outputFormat == "" {
+		c.Abort()
+		errUnsupportedFormat.Emit(c)
+		return
 	}
 
-	if !slices.Contains(databaseKeys, database) {
+	database, known := dwd.LookupDatabase(c.Param("database"))
+	if !known {
 		c.Abort()
 		errUnknownDatabase.Emit(c)
 		return
 	}
 
-	res, err := http.Get(dwd.Databases[database])
+	ok, err := reachable(database.BaseUrl())
 	if err != nil {
 		c.Abort()
 		_ = c.Error(err)
 		return
 	}
 
-	if res.StatusCode != http.StatusOK {
+	if !ok {
 		c.Abort()
 		errDatabaseUnreachable.Emit(c)
 		return
@@ -139,14 +137,45 @@ func Timeseries(c *gin.Context) { //nolint:maintidx
 		return
 	}
 
-	if !slices.Contains(dwd.AvailableClimateObservationProducts[granularity], product) {
+	if !dwd.SupportsProduct(database, granularity, product) {
 		c.Abort()
 		errUnsupportedGranularity.Emit(c)
 		return
 	}
 
+	qualityFilter, err := parseQualityFilter(c)
+	if err != nil {
+		c.Abort()
+		errInvalidQualityFilter.Emit(c)
+		return
+	}
+
+	selectedLabels := parseLabels(c)
+
+	periods, err := parsePeriods(c)
+	if err != nil {
+		c.Abort()
+		errInvalidPeriod.Emit(c)
+		return
+	}
+
+	resampleOptions, err := parseResampleOptions(c)
+	if err != nil {
+		c.Abort()
+		errInvalidResample.Emit(c)
+		return
+	}
+
+	if resampleOptions != nil {
+		if err := resampleOptions.Validate(granularity); err != nil {
+			c.Abort()
+			errInvalidResample.Emit(c)
+			return
+		}
+	}
+
 	// now request the station list for the product
-	stations, err := dwd.DiscoverStations(dwd.Databases[database], granularity, product)
+	stations, err := dwd.CachedStations(c, database.Name(), granularity, product)
 	if err != nil {
 		c.Abort()
 		errStationValidationFailed.Emit(c)
@@ -188,6 +217,12 @@ func Timeseries(c *gin.Context) { //nolint:maintidx
 		return
 	}
 
+	// the availability of forecasts only covers the run current while
+	// discovering the stations, while the download uses the latest run
+	if dwd.IsForecast(database) {
+		goto startDownload
+	}
+
 	if requestedRange.Start.Before(dataAvailableFrom.Start) {
 		c.Abort()
 		errTimeseriesStartTooEarly.Emit(c)
@@ -202,109 +237,94 @@ func Timeseries(c *gin.Context) { //nolint:maintidx
 
 startDownload:
 
-	dataFiles, descriptionFiles, err := dwd.DownloadFiles(database, station.ID, product, granularity)
+	dataFiles, descriptionFiles, err := database.DownloadFiles(station.ID, product, granularity, dwd.DownloadFilter{
+		Periods: periods,
+		Start:   requestedRange.Start,
+		End:     requestedRange.End,
+	})
 	if err != nil {
 		c.Abort()
 		_ = c.Error(err)
 		return
 	}
+	// the files stay pinned in the cache until the response has been written
+	defer dwd.ReleaseFiles(dataFiles, descriptionFiles)
 
 	var series v2.Timeseries
 
-	for _, descriptionFile := range descriptionFiles {
-		var file v2.File
-
-		if strings.HasPrefix(descriptionFile[0], "BESCHREIBUNG") {
-			file.Name = "[DE] Datensatzbeschreibung"
-		}
-
-		if strings.HasPrefix(descriptionFile[0], "DESCRIPTION") {
-			file.Name = "[EN] Dataset Description"
-		}
+	series.DescriptionFiles, err = readDescriptionFiles(descriptionFiles)
+	if err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
 
-		if file.Name == "" {
-			file.Name = strings.Trim(strings.SplitAfterN(descriptionFile[0], ".", 2)[0], ".") //nolint:mnd
+	var archives []*dwd.Archive
+	defer func() {
+		for _, archive := range archives {
+			_ = archive.Close()
 		}
+	}()
 
-		f, err := os.Open(descriptionFile[1])
-		if err != nil {
-			c.Abort()
-			_ = c.Error(err)
-			return
-		}
+	allMetadata := make([]v2.FieldMetadata, 0)
+	var labels []string
+	sequences := make([]iter.Seq2[v2.Datapoint, error], 0, len(dataFiles))
 
-		mime, err := mimetype.DetectReader(f)
+	for _, dataFile := range dataFiles {
+		archive, err := database.OpenDataFile(dataFile, station.ID, product, granularity, selectedLabels)
 		if err != nil {
 			c.Abort()
 			_ = c.Error(err)
 			return
 		}
-		file.MimeType = mime.String()
-
-		var buf bytes.Buffer
-		enc := base64.NewEncoder(base64.StdEncoding, &buf)
-		_, _ = f.Seek(0, io.SeekStart)
-		_, err = io.Copy(enc, f)
-		if err != nil {
-			c.Abort()
-			_ = c.Error(err)
-			return
+		archives = append(archives, archive)
+		allMetadata = mergeMetadata(allMetadata, archive.Metadata)
+		for _, label := range archive.Labels {
+			if !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
 		}
-		_ = enc.Close()
-
-		file.Content = buf.String()
-
-		series.DescriptionFiles = append(series.DescriptionFiles, file)
-
+		sequences = append(sequences, archive.Datapoints())
 	}
 
-	allDatapoints := make([]v2.Datapoint, 0)
-	allMetadata := make([]v2.FieldMetadata, 0)
-
-	for _, dataFile := range dataFiles {
-		datapoints, metadata, err := dwd.HandleArchive(dataFile)
-		if err != nil {
+	for _, label := range selectedLabels {
+		if !slices.Contains(labels, label) {
 			c.Abort()
-			_ = c.Error(err)
+			errUnknownLabel.Emit(c)
 			return
 		}
-		allDatapoints = append(allDatapoints, datapoints...)
-		allMetadata = append(allMetadata, metadata...)
 	}
 
-	series.Datapoints = allDatapoints
+	series.Metadata = allMetadata
 
-	if !requestedRange.Start.IsZero() || !requestedRange.End.IsZero() {
-		if requestedRange.End.IsZero() {
-			requestedRange.End = time.Now()
-		}
-		datapoints := make([]v2.Datapoint, 0)
+	datapoints := dwd.DeduplicateDatapoints(dwd.MergeDatapoints(sequences...))
+	datapoints = dwd.FilterTimeRange(datapoints, requestedRange.Start, requestedRange.End)
 
-		for _, dp := range allDatapoints {
-			if (dp.Timestamp.Equal(requestedRange.Start) || dp.Timestamp.After(requestedRange.Start)) &&
-				(dp.Timestamp.Equal(requestedRange.End) || dp.Timestamp.Before(requestedRange.End)) {
-				datapoints = append(datapoints, dp)
-			}
-		}
+	var qualitySummary dwd.QualityFilterSummary
+	if qualityFilter.Active() {
+		datapoints, qualitySummary = dwd.FilterQuality(datapoints, qualityFilter)
+	}
 
-		series.Datapoints = datapoints
+	if resampleOptions != nil {
+		datapoints = dwd.Resample(datapoints, *resampleOptions)
+	}
 
+	output := timeseriesOutput{
+		Series:         series,
+		Labels:         labels,
+		Datapoints:     datapoints,
+		QualitySummary: qualitySummary,
 	}
 
-	for {
-		if slices.IsSortedFunc(series.Datapoints, func(this, other v2.Datapoint) int {
-			return this.Timestamp.Compare(other.Timestamp)
-		}) {
-			break
+	if err := writeTimeseries(c, outputFormat, output); err != nil {
+		c.Abort()
+		if !c.Writer.Written() {
+			_ = c.Error(err)
+			return
 		}
-
-		slices.SortFunc(series.Datapoints, func(this, other v2.Datapoint) int {
-			return this.Timestamp.Compare(other.Timestamp)
-		})
+		// the response has already been partially sent, so the error can
+		// only be logged
+		slog.Error("unable to stream timeseries", "error", err)
 	}
 
-	series.Metadata = allMetadata
-
-	c.JSON(http.StatusOK, series)
-
 }
@@ -0,0 +1,261 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+	"github.com/wisdom-oss/common-go/v3/types"
+
+	dwd "microservice/internal/dwd/v2"
+)
+
+var errUnknownWarningSource = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Unknown Warning Source",
+	Detail: "The supplied warning source is unknown. Use either communeUnion or district",
+}
+
+var errInvalidSeverity = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Severity",
+	Detail: "The supplied severity is unknown. Use one of minor, moderate, severe or extreme",
+}
+
+var errInvalidWarningLocation = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Location",
+	Detail: "The location needs to be supplied either as coordinate using the lon and lat query parameters, as station using the database and station query parameters or as GeoJSON point (or point feature) in the request body", //nolint:lll
+}
+
+var errInvalidBoundingBox = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Invalid Bounding Box",
+	Detail: "The bounding box needs to be supplied as minLon,minLat,maxLon,maxLat",
+}
+
+var errWarningRangeTooLarge = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.1",
+	Status: http.StatusBadRequest,
+	Title:  "Warning Range Too Large",
+	Detail: "The range of the warnings exceeds the maximum of 48 hours. Supply a start at most 48 hours before the end (or before now if no end is supplied)", //nolint:lll
+}
+
+// maxWarningRange limits the range the warnings are requested for, since every
+// snapshot issued during the range is downloaded and read.
+const maxWarningRange = 48 * time.Hour
+
+var errUnknownStation = types.ServiceError{
+	Type:   "https://datatracker.ietf.org/doc/html/rfc9110#section-15.5.5",
+	Status: http.StatusNotFound,
+	Title:  "Station Unknown",
+	Detail: "The supplied station is not offered by the database",
+}
+
+var (
+	errWarningLocationAmbiguous = errors.New("more than one location supplied")
+	errWarningLocationNoPoint   = errors.New("the location needs to be a point")
+	errUnknownDatabaseName      = errors.New("unknown database")
+	errStationMissing           = errors.New("station not found")
+	errStationLookupFailed      = errors.New("station lookup failed")
+	errMalformedBoundingBox     = errors.New("malformed bounding box")
+)
+
+// Warnings lists the weather warnings issued by the DWD as GeoJSON features.
+// The warnings may be limited to a time range, a minimum severity and either
+// a location or a bounding box.
+// The location is supplied as coordinate, as station of a database or as
+// GeoJSON point (e.g. a station feature) in the request body.
+func Warnings(c *gin.Context) {
+	var parameters struct {
+		Source   string    `form:"source"`
+		Start    time.Time `form:"start"`
+		End      time.Time `form:"end"`
+		Severity string    `form:"severity"`
+	}
+	if err := c.ShouldBindQuery(&parameters); err != nil {
+		c.Abort()
+		errTimeseriesParseError.Emit(c)
+		return
+	}
+
+	if parameters.Source == "" {
+		parameters.Source = dwd.DefaultWarningSource
+	}
+
+	if !parameters.Start.IsZero() && !parameters.End.IsZero() && parameters.Start.After(parameters.End) {
+		c.Abort()
+		errTimeseriesBoundaryError.Emit(c)
+		return
+	}
+
+	if warningRangeTooLarge(parameters.Start, parameters.End, time.Now()) {
+		c.Abort()
+		errWarningRangeTooLarge.Emit(c)
+		return
+	}
+
+	filter := dwd.WarningFilter{
+		Start: parameters.Start,
+		End:   parameters.End,
+	}
+
+	if parameters.Severity != "" {
+		if err := filter.MinimumSeverity.Parse(parameters.Severity); err != nil {
+			c.Abort()
+			errInvalidSeverity.Emit(c)
+			return
+		}
+	}
+
+	boundingBox, err := parseBoundingBox(c)
+	if err != nil {
+		c.Abort()
+		errInvalidBoundingBox.Emit(c)
+		return
+	}
+	filter.BoundingBox = boundingBox
+
+	filter.Point, err = parseWarningLocation(c)
+	if err != nil {
+		c.Abort()
+		switch {
+		case errors.Is(err, errUnknownDatabaseName):
+			errUnknownDatabase.Emit(c)
+		case errors.Is(err, errStationMissing):
+			errUnknownStation.Emit(c)
+		case errors.Is(err, errStationLookupFailed):
+			errStationValidationFailed.Emit(c)
+		default:
+			errInvalidWarningLocation.Emit(c)
+		}
+		return
+	}
+
+	warnings, err := dwd.Warnings(parameters.Source, filter)
+	if errors.Is(err, dwd.ErrUnknownWarningSource) {
+		c.Abort()
+		errUnknownWarningSource.Emit(c)
+		return
+	}
+	if err != nil {
+		c.Abort()
+		_ = c.Error(err)
+		return
+	}
+
+	features := make([]*geojson.Feature, 0, len(warnings))
+	for _, warning := range warnings {
+		features = append(features, warning.ToFeature())
+	}
+
+	c.JSON(http.StatusOK, &geojson.FeatureCollection{Features: features})
+}
+
+// warningRangeTooLarge checks if the range exceeds [maxWarningRange].
+// A range without an end lasts until now, while a range without a start would
+// select every snapshot issued before its end and is always too large.
+// If neither start nor end are supplied, only the latest snapshot is read.
+func warningRangeTooLarge(start, end, now time.Time) bool {
+	if start.IsZero() && end.IsZero() {
+		return false
+	}
+	if start.IsZero() {
+		return true
+	}
+	if end.IsZero() {
+		end = now
+	}
+	return end.Sub(start) > maxWarningRange
+}
+
+// parseWarningLocation reads the location from the lon and lat query
+// parameters, from the database and station query parameters or from the
+// GeoJSON point or feature in the request body.
+// If no location has been supplied, nil is returned.
+func parseWarningLocation(c *gin.Context) (*geom.Point, error) {
+	var locations []*geom.Point
+
+	point, err := parseCoordinate(c)
+	switch {
+	case err == nil:
+		locations = append(locations, point)
+	case !errors.Is(err, errMissingCoordinate):
+		return nil, err
+	}
+
+	if stationID, supplied := c.GetQuery("station"); supplied {
+		database, known := dwd.LookupDatabase(c.Query("database"))
+		if !known {
+			return nil, errUnknownDatabaseName
+		}
+		station, found, err := dwd.LookupStation(c, database, stationID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errStationLookupFailed, err)
+		}
+		if !found {
+			return nil, errStationMissing
+		}
+		locations = append(locations, station.Location)
+	}
+
+	geometry, err := parseBodyGeometry(c)
+	switch {
+	case err == nil:
+		point, isPoint := geometry.(*geom.Point)
+		if !isPoint {
+			return nil, errWarningLocationNoPoint
+		}
+		locations = append(locations, point)
+	case !errors.Is(err, errMissingGeometry):
+		return nil, err
+	}
+
+	switch len(locations) {
+	case 0:
+		return nil, nil
+	case 1:
+		return locations[0], nil
+	default:
+		return nil, errWarningLocationAmbiguous
+	}
+}
+
+// parseBoundingBox reads the bounding box from the bbox query parameter,
+// which contains the box as minLon,minLat,maxLon,maxLat.
+// If no bounding box has been supplied, nil is returned.
+func parseBoundingBox(c *gin.Context) (*geom.Bounds, error) {
+	bbox, supplied := c.GetQuery("bbox")
+	if !supplied {
+		return nil, nil
+	}
+
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 { //nolint:mnd
+		return nil, errMalformedBoundingBox
+	}
+
+	coordinates := make([]float64, len(parts))
+	for idx, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		coordinates[idx] = coordinate
+	}
+	if coordinates[0] > coordinates[2] || coordinates[1] > coordinates[3] {
+		return nil, errMalformedBoundingBox
+	}
+
+	return geom.NewBounds(geom.XY).Set(coordinates...), nil
+}
@@ -0,0 +1,31 @@
+package v2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarningRangeTooLarge(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		tooLarge   bool
+	}{
+		{name: "latest warnings", tooLarge: false},
+		{name: "start within the range", start: now.Add(-47 * time.Hour), tooLarge: false},
+		{name: "start before the range", start: now.Add(-49 * time.Hour), tooLarge: true},
+		{name: "range at the maximum", start: now.Add(-72 * time.Hour), end: now.Add(-24 * time.Hour), tooLarge: false},
+		{name: "range above the maximum", start: now.Add(-72 * time.Hour), end: now.Add(-23 * time.Hour), tooLarge: true},
+		{name: "end without start", end: now, tooLarge: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warningRangeTooLarge(tt.start, tt.end, now); got != tt.tooLarge {
+				t.Errorf("expected %t, got %t", tt.tooLarge, got)
+			}
+		})
+	}
+}
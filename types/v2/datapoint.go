@@ -7,9 +7,29 @@ import (
 )
 
 type Datapoint struct {
-	Label        string                `json:"label"`
-	Timestamp    time.Time             `json:"ts"`
-	Value        any                   `json:"value"`
-	Unit         *string               `json:"unit"`
-	QualityLevel *dwdTypes.QualityFlag `json:"qualityLevel"`
+	Label        string                            `json:"label"`
+	Timestamp    time.Time                         `json:"ts"`
+	Value        any                               `json:"value"`
+	Unit         *string                           `json:"unit"`
+	QualityLevel *dwdTypes.QualityControlProcedure `json:"qualityLevel"`
+
+	// ReferencePeriod contains the period a multi-annual mean has been
+	// calculated for and is not set for other datapoints.
+	ReferencePeriod *DateTimeRange `json:"referencePeriod,omitempty"`
+
+	// Interval contains the start and end of the observation as given by the
+	// DWD for products observing an interval (e.g. a month) and is not set
+	// for other datapoints.
+	// The timestamp of these datapoints is the start of the interval.
+	Interval *DateTimeRange `json:"interval,omitempty"`
+
+	// Period contains the period folder of the data file the datapoint has
+	// been read from.
+	Period dwdTypes.Period `json:"period,omitempty"`
+
+	// Phenology contains the plant and phase of a phenological observation
+	// and is not set for other datapoints.
+	// The value of these datapoints is the day of the year the phase has been
+	// observed on.
+	Phenology *PhenologyObservation `json:"phenology,omitempty"`
 }
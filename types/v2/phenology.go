@@ -0,0 +1,18 @@
+package v2
+
+// PhenologyObservation describes the plant and phase of a phenological
+// observation.
+type PhenologyObservation struct {
+	// PlantID and Plant contain the code and the name of the observed plant.
+	PlantID int    `json:"plantId"`
+	Plant   string `json:"plant"`
+
+	// PhaseID and Phase contain the code and the name of the observed phase.
+	PhaseID int    `json:"phaseId"`
+	Phase   string `json:"phase"`
+
+	// ReferenceYear contains the vegetation year the observation belongs to,
+	// which may differ from the year of the observation for phases observed
+	// before the turn of the year (e.g. the sowing of winter crops).
+	ReferenceYear int `json:"referenceYear"`
+}
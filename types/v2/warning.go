@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"time"
+
+	"github.com/twpayne/go-geom"
+	"github.com/twpayne/go-geom/encoding/geojson"
+
+	"microservice/internal/dwd/v2/dwdTypes"
+)
+
+// Warning is a weather warning issued by the DWD as CAP alert.
+type Warning struct {
+	ID          string
+	Sent        time.Time
+	Status      string
+	MessageType string
+	// References contains the ids of the warnings updated or cancelled by
+	// this warning.
+	References  []string
+	Event       string
+	Severity    dwdTypes.Severity
+	Urgency     string
+	Certainty   string
+	Onset       time.Time
+	Expires     time.Time
+	Headline    string
+	Description string
+	Instruction string
+	Areas       []WarningArea
+}
+
+// WarningArea is an area affected by a warning.
+type WarningArea struct {
+	Description string
+	// Polygons contains the outlines of the area as longitude and latitude in
+	// WGS84.
+	Polygons []*geom.Polygon
+	// Geocodes contains the codes identifying the area (e.g. WARNCELLID).
+	Geocodes map[string][]string
+}
+
+// Active checks if the warning is in effect during the range.
+// Warnings without an expiry are in effect until they are cancelled.
+func (w Warning) Active(start, end time.Time) bool {
+	if !end.IsZero() && w.Onset.After(end) {
+		return false
+	}
+	return start.IsZero() || w.Expires.IsZero() || !w.Expires.Before(start)
+}
+
+func (w Warning) MarshalJSON() ([]byte, error) {
+	return w.ToFeature().MarshalJSON()
+}
+
+// ToFeature converts the warning into a GeoJSON feature whose geometry
+// combines the polygons of all areas.
+func (w Warning) ToFeature() *geojson.Feature {
+	geometry := geom.NewMultiPolygon(geom.XY)
+	areas := make([]map[string]any, 0, len(w.Areas))
+	for _, area := range w.Areas {
+		for _, polygon := range area.Polygons {
+			_ = geometry.Push(polygon)
+		}
+		areas = append(areas, map[string]any{
+			"description": area.Description,
+			"geocodes":    area.Geocodes,
+		})
+	}
+
+	properties := map[string]any{
+		"id":          w.ID,
+		"sent":        w.Sent,
+		"status":      w.Status,
+		"messageType": w.MessageType,
+		"event":       w.Event,
+		"severity":    w.Severity,
+		"urgency":     w.Urgency,
+		"certainty":   w.Certainty,
+		"onset":       w.Onset,
+		"expires":     nil,
+		"headline":    w.Headline,
+		"description": w.Description,
+		"instruction": w.Instruction,
+		"areas":       areas,
+	}
+	if !w.Expires.IsZero() {
+		properties["expires"] = w.Expires
+	}
+
+	return &geojson.Feature{
+		ID:         w.ID,
+		Geometry:   geometry,
+		Properties: properties,
+	}
+}